@@ -2,8 +2,14 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/mehmetalisavas/message-sender/config"
@@ -11,6 +17,8 @@ import (
 	"github.com/mehmetalisavas/message-sender/internal/api"
 	"github.com/mehmetalisavas/message-sender/internal/db/mysql"
 	"github.com/mehmetalisavas/message-sender/internal/db/redis"
+	"github.com/mehmetalisavas/message-sender/internal/models"
+	"github.com/mehmetalisavas/message-sender/internal/processing"
 	"github.com/mehmetalisavas/message-sender/internal/pubsub"
 	"github.com/mehmetalisavas/message-sender/internal/route"
 	"github.com/mehmetalisavas/message-sender/internal/schedule"
@@ -19,11 +27,15 @@ import (
 	"github.com/sethvargo/go-envconfig"
 )
 
-const defaultRequestTimeout = 10  // seconds
-const defaultTickerInterval = 120 // seconds
+const defaultTickerInterval = 120  // seconds
+const defaultStateSyncInterval = 5 // seconds
+const defaultScheduleInterval = 30 // seconds
+const defaultShutdownTimeout = 10  // seconds
 
 func main() {
-	ctx := context.Background()
+	// The context is cancelled on SIGINT or SIGTERM, which stops the workers and flushes their statuses.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	c := config.New()
 	if err := envconfig.Process(ctx, &c); err != nil {
@@ -42,21 +54,139 @@ func main() {
 		log.Fatalf("error while starting cache service: %v \n", err)
 	}
 
-	notificationService := notification.NewNotificationService(c.NotificationServiceURL, time.Duration(defaultRequestTimeout)*time.Second)
+	processingController := processing.NewController(models.ProcessingStateStarted)
+	processingSyncer := processing.NewSyncer(processingController, sqlStorage, time.Duration(defaultStateSyncInterval)*time.Second)
+	if err := processingSyncer.Load(ctx); err != nil {
+		log.Fatalf("error while loading processing state: %v \n", err)
+	}
+	go processingSyncer.Watch(ctx)
+
+	notificationSenders, circuitBreakers, err := newNotificationRegistry(c, time.Duration(c.RequestTimeoutSeconds)*time.Second)
+	if err != nil {
+		log.Fatalf("error while configuring notification channels: %v \n", err)
+	}
 
 	scheduler := schedule.NewScheduler(sqlStorage)
-	messageProducer := pubsub.NewMessageProducer(&c, sqlStorage, scheduler.MessageBus(), defaultTickerInterval)
+	visibilityTimeout := time.Duration(c.VisibilityTimeoutSeconds) * time.Second
+	messageProducer := pubsub.NewMessageProducer(processingController, sqlStorage, scheduler.MessageBus(), defaultTickerInterval, visibilityTimeout)
 	scheduler.AddProducer(messageProducer)
-	messageConsumer := pubsub.NewMessageConsumer(sqlStorage, scheduler.MessageBus(), notificationService, cacheService)
+	scheduler.AddProducer(schedule.NewRecurringProducer(sqlStorage, defaultScheduleInterval))
+	messageConsumer := pubsub.NewMessageConsumer(sqlStorage, scheduler.MessageBus(), notificationSenders, cacheService, c.DefaultCountryCode, time.Duration(c.DeliveryTimeoutSeconds)*time.Second, time.Duration(c.DedupeWindowSeconds)*time.Second, visibilityTimeout)
 	scheduler.AddConsumer(messageConsumer)
 
-	go scheduler.Start(ctx, 2) // start with 2 workers
+	schedulerDone := make(chan struct{})
+	go func() {
+		defer close(schedulerDone)
+		scheduler.Start(ctx, 2) // start with 2 workers
+	}()
 
-	api := api.New(&c, sqlStorage)
+	api := api.New(&c, sqlStorage, processingController, processingSyncer, circuitBreakers)
 
 	routers := route.Routers(api)
 
-	log.Printf("Starting server on :8080")
-	log.Fatal(http.ListenAndServe(":8080", routers))
+	server := &http.Server{Addr: ":8080", Handler: routers}
+	go func() {
+		log.Printf("Starting server on :8080")
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Printf("shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(defaultShutdownTimeout)*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("error while shutting down the server: %v \n", err)
+	}
+
+	// Wait for the workers to stop and the status writer to flush the queued statuses.
+	<-schedulerDone
+}
+
+// newNotificationRegistry registers the senders of the configured notification channels.
+// Every sender, or every provider when SMS is routed, is wrapped in a circuit breaker.
+func newNotificationRegistry(c config.Config, timeout time.Duration) (*notification.Registry, []*notification.CircuitBreaker, error) {
+	registry := notification.NewRegistry()
+	breakerConfig := notification.BreakerConfig{
+		FailureThreshold:    c.BreakerFailureThreshold,
+		OpenTimeout:         time.Duration(c.BreakerOpenSeconds) * time.Second,
+		HalfOpenMaxRequests: notification.DefaultBreakerConfig.HalfOpenMaxRequests,
+	}
+	auth, err := notification.NewAuthenticator(notification.AuthConfig{
+		Type:         notification.AuthType(c.NotificationAuthType),
+		HeaderName:   c.NotificationAuthHeader,
+		Token:        c.NotificationAuthToken,
+		Username:     c.NotificationAuthUsername,
+		Password:     c.NotificationAuthPassword,
+		TokenURL:     c.NotificationOAuthTokenURL,
+		ClientID:     c.NotificationOAuthClientID,
+		ClientSecret: c.NotificationOAuthSecret,
+		Scopes:       c.NotificationOAuthScopes,
+		HMACSecret:   c.NotificationSigningSecret,
+	}, timeout)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	breakers := make([]*notification.CircuitBreaker, 0)
+	withBreaker := func(name string, sender notification.NotificationSender) notification.NotificationSender {
+		breaker := notification.NewCircuitBreaker(name, sender, breakerConfig)
+		breakers = append(breakers, breaker)
+		return breaker
+	}
+
+	if c.NotificationProviders != "" && c.NotificationRoutes != "" {
+		providers, routes, err := notification.ParseRoutingConfig(c.NotificationProviders, c.NotificationRoutes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		senders := make(map[string]notification.NotificationSender, len(providers))
+		for _, p := range providers {
+			opts := []notification.Option{notification.WithAuthenticator(auth)}
+			if p.Mapping != nil {
+				mapping, err := notification.NewMapping(*p.Mapping)
+				if err != nil {
+					return nil, nil, fmt.Errorf("provider %s: %w", p.Name, err)
+				}
+				opts = append(opts, notification.WithMapping(mapping))
+			}
+			senders[p.Name] = withBreaker(p.Name, notification.NewNotificationService(p.URL, timeout, opts...))
+		}
+
+		router, err := notification.BuildRouter(providers, routes, senders)
+		if err != nil {
+			return nil, nil, err
+		}
+		registry.Register(notification.ChannelSMS, router)
+	} else if c.SMSGatewayURL != "" {
+		registry.Register(notification.ChannelSMS, withBreaker(string(notification.ChannelSMS), notification.NewSMSGatewaySender(c.SMSGatewayURL, c.SMSGatewayFrom, timeout)))
+	} else {
+		opts := []notification.Option{notification.WithAuthenticator(auth)}
+		if c.NotificationMapping != "" {
+			var mappingConfig notification.MappingConfig
+			if err := json.Unmarshal([]byte(c.NotificationMapping), &mappingConfig); err != nil {
+				return nil, nil, fmt.Errorf("invalid notification mapping: %w", err)
+			}
+			mapping, err := notification.NewMapping(mappingConfig)
+			if err != nil {
+				return nil, nil, err
+			}
+			opts = append(opts, notification.WithMapping(mapping))
+		}
+		registry.Register(notification.ChannelSMS, withBreaker(string(notification.ChannelSMS), notification.NewNotificationService(c.NotificationServiceURL, timeout, opts...)))
+	}
+	if c.SMTPHost != "" {
+		registry.Register(notification.ChannelEmail, withBreaker(string(notification.ChannelEmail), notification.NewEmailSender(c.SMTPHost, c.SMTPPort, c.SMTPUsername, c.SMTPPassword, c.SMTPFrom, c.EmailSubject, timeout)))
+	}
+	if c.ChatWebhookURL != "" {
+		registry.Register(notification.ChannelChat, withBreaker(string(notification.ChannelChat), notification.NewChatWebhookSender(c.ChatWebhookURL, timeout)))
+	}
+	if c.PushServiceURL != "" {
+		registry.Register(notification.ChannelPush, withBreaker(string(notification.ChannelPush), notification.NewPushSender(c.PushServiceURL, c.PushTitle, timeout)))
+	}
 
+	return registry, breakers, nil
 }
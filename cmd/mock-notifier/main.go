@@ -0,0 +1,28 @@
+// Command mock-notifier runs a fake notification provider for local development
+// and integration tests, point NOTIFICATION_SERVICE_URL at it instead of a real provider.
+//
+// POST any path to send a message and GET any path to list the received messages.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/mehmetalisavas/message-sender/pkg/mocknotifier"
+)
+
+func main() {
+	var config mocknotifier.Config
+	addr := flag.String("addr", ":9090", "address to listen on")
+	flag.DurationVar(&config.Latency, "latency", 0, "delay of every response")
+	flag.Float64Var(&config.ErrorRate, "error-rate", 0, "share of requests answered with 500, between 0 and 1")
+	flag.Float64Var(&config.RateLimitRate, "rate-limit-rate", 0, "share of requests answered with 429, between 0 and 1")
+	flag.DurationVar(&config.RetryAfter, "retry-after", 0, "Retry-After of 429 responses")
+	flag.StringVar(&config.CallbackURL, "callback-url", "", "URL receiving delivery receipts")
+	flag.DurationVar(&config.CallbackDelay, "callback-delay", 0, "delay of delivery receipts")
+	flag.Parse()
+
+	log.Printf("Starting mock notifier on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, mocknotifier.New(config)))
+}
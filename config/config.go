@@ -9,15 +9,70 @@ type Config struct {
 	NotificationServiceURL string `env:"NOTIFICATION_SERVICE_URL,required"`
 	RedisHost              string `env:"REDIS_HOST,required"`
 	RedisPassword          string `env:"REDIS_PASSWORD,required"`
-	IsMessageProcessing    bool
-}
 
-func New() Config {
-	return Config{
-		IsMessageProcessing: true,
-	}
+	// Country calling code of phone numbers given without one, e.g. 90.
+	DefaultCountryCode string `env:"DEFAULT_COUNTRY_CODE, default=90"`
+	// Maximum number of segments of an SMS, zero means no limit.
+	MaxSMSSegments int `env:"MAX_SMS_SEGMENTS, default=3"`
+
+	// Optional notification channels, a channel is enabled when its endpoint is set.
+	// SMS falls back to the notification service when no gateway is set.
+	SMSGatewayURL  string `env:"SMS_GATEWAY_URL"`
+	SMSGatewayFrom string `env:"SMS_GATEWAY_FROM"`
+	SMTPHost       string `env:"SMTP_HOST"`
+	SMTPPort       int    `env:"SMTP_PORT, default=587"`
+	SMTPUsername   string `env:"SMTP_USERNAME"`
+	SMTPPassword   string `env:"SMTP_PASSWORD"`
+	SMTPFrom       string `env:"SMTP_FROM"`
+	EmailSubject   string `env:"EMAIL_SUBJECT, default=Notification"`
+	ChatWebhookURL string `env:"CHAT_WEBHOOK_URL"`
+	PushServiceURL string `env:"PUSH_SERVICE_URL"`
+	PushTitle      string `env:"PUSH_TITLE"`
+
+	// Optional JSON encoded request/response mapping of the notification service, see notification.MappingConfig.
+	NotificationMapping string `env:"NOTIFICATION_MAPPING"`
+
+	// Optional SMS provider routing with failover, JSON encoded. See notification.ProviderConfig
+	// and notification.RouteConfig. It replaces the SMS sender when both are set.
+	NotificationProviders string `env:"NOTIFICATION_PROVIDERS"`
+	NotificationRoutes    string `env:"NOTIFICATION_ROUTES"`
+
+	// Authentication of the notification service requests, see notification.AuthConfig.
+	// NOTIFICATION_AUTH_TYPE is one of none, header, bearer, basic or oauth2.
+	NotificationAuthType      string   `env:"NOTIFICATION_AUTH_TYPE, default=none"`
+	NotificationAuthHeader    string   `env:"NOTIFICATION_AUTH_HEADER"`
+	NotificationAuthToken     string   `env:"NOTIFICATION_AUTH_TOKEN"`
+	NotificationAuthUsername  string   `env:"NOTIFICATION_AUTH_USERNAME"`
+	NotificationAuthPassword  string   `env:"NOTIFICATION_AUTH_PASSWORD"`
+	NotificationOAuthTokenURL string   `env:"NOTIFICATION_OAUTH_TOKEN_URL"`
+	NotificationOAuthClientID string   `env:"NOTIFICATION_OAUTH_CLIENT_ID"`
+	NotificationOAuthSecret   string   `env:"NOTIFICATION_OAUTH_CLIENT_SECRET"`
+	NotificationOAuthScopes   []string `env:"NOTIFICATION_OAUTH_SCOPES"`
+	NotificationSigningSecret string   `env:"NOTIFICATION_SIGNING_SECRET"`
+
+	// Shared secret the SMS provider signs the inbound message webhook requests with, see notification.HMACSigner.
+	// The webhook rejects every request while it is not set.
+	InboundWebhookSecret string `env:"INBOUND_WEBHOOK_SECRET"`
+
+	// Timeout of a single request to a notification sender and of the whole delivery
+	// of a message, retries included. Messages not delivered in time are marked as expired.
+	RequestTimeoutSeconds  int `env:"REQUEST_TIMEOUT_SECONDS, default=10"`
+	DeliveryTimeoutSeconds int `env:"DELIVERY_TIMEOUT_SECONDS, default=30"`
+
+	// Messages are leased for the visibility timeout while they are processed, workers extend the lease
+	// of long sends. Messages whose lease expired, e.g. because their worker crashed, are processed again.
+	VisibilityTimeoutSeconds int `env:"VISIBILITY_TIMEOUT_SECONDS, default=300"`
+
+	// Messages with the same recipient and content as a message processed within the window
+	// are marked as deduplicated instead of being sent, zero disables it. It is disabled by default,
+	// since legitimate repeats like OTP resends would be dropped.
+	DedupeWindowSeconds int `env:"DEDUPE_WINDOW_SECONDS, default=0"`
+
+	// Circuit breaker around every notification sender and routed provider.
+	BreakerFailureThreshold int `env:"BREAKER_FAILURE_THRESHOLD, default=5"`
+	BreakerOpenSeconds      int `env:"BREAKER_OPEN_SECONDS, default=30"`
 }
 
-func (c *Config) SetMessageProcessing(enable bool) {
-	c.IsMessageProcessing = enable
+func New() Config {
+	return Config{}
 }
@@ -1,18 +1,57 @@
 package api
 
 import (
+	"fmt"
+	"mime"
+	"net/http"
+
 	"github.com/mehmetalisavas/message-sender/config"
+	"github.com/mehmetalisavas/message-sender/internal/processing"
 	"github.com/mehmetalisavas/message-sender/internal/service"
+	"github.com/mehmetalisavas/message-sender/pkg/services/notification"
+)
+
+const (
+	// actorHeader is the request header that identifies who issued an admin command.
+	actorHeader = "X-Actor"
+	// maxActorHeaderLength bounds the header value recorded along the remote address.
+	maxActorHeaderLength = 64
 )
 
 type Api struct {
-	config         *config.Config
-	storageService service.Storage
+	config               *config.Config
+	storageService       service.Storage
+	processingController *processing.Controller
+	processingSyncer     *processing.Syncer
+	circuitBreakers      []*notification.CircuitBreaker
 }
 
-func New(cfg *config.Config, storageService service.Storage) *Api {
+func New(cfg *config.Config, storageService service.Storage, processingController *processing.Controller, processingSyncer *processing.Syncer, circuitBreakers []*notification.CircuitBreaker) *Api {
 	return &Api{
-		config:         cfg,
-		storageService: storageService,
+		config:               cfg,
+		storageService:       storageService,
+		processingController: processingController,
+		processingSyncer:     processingSyncer,
+		circuitBreakers:      circuitBreakers,
+	}
+}
+
+// hasContentType reports whether the request body has the given media type, ignoring parameters like charset.
+func hasContentType(r *http.Request, mediaType string) bool {
+	parsed, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && parsed == mediaType
+}
+
+// requestActor returns the identity of the caller recorded in audit logs. The API isn't authenticated,
+// so the remote address is recorded as the caller; the X-Actor header can be set by anyone, it is only
+// recorded along the address and labeled as unverified.
+func requestActor(r *http.Request) string {
+	claimed := r.Header.Get(actorHeader)
+	if claimed == "" {
+		return r.RemoteAddr
+	}
+	if runes := []rune(claimed); len(runes) > maxActorHeaderLength {
+		claimed = string(runes[:maxActorHeaderLength])
 	}
+	return fmt.Sprintf("%s (unverified %s: %q)", r.RemoteAddr, actorHeader, claimed)
 }
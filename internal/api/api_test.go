@@ -1,18 +1,142 @@
 package api
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/mehmetalisavas/message-sender/config"
+	"github.com/mehmetalisavas/message-sender/internal/models"
+	"github.com/mehmetalisavas/message-sender/pkg/services/notification"
 )
 
 func TestNew(t *testing.T) {
 	cfg := &config.Config{}
 
-	apiInstance := New(cfg, nil)
+	apiInstance := New(cfg, nil, nil, nil, nil)
 
 	if apiInstance == nil {
 		t.Errorf("expected apiInstance to be non-nil")
 	}
 
 }
+
+func TestHasContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        bool
+	}{
+		{"application/json", true},
+		{"application/json; charset=utf-8", true},
+		{"Application/JSON", true},
+		{"application/x-www-form-urlencoded", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.Header.Set("Content-Type", tt.contentType)
+		if got := hasContentType(r, "application/json"); got != tt.want {
+			t.Errorf("hasContentType(%q) = %v, want %v", tt.contentType, got, tt.want)
+		}
+	}
+}
+
+func TestRequestActor(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", "192.0.2.1:1234"},
+		{"alice", `192.0.2.1:1234 (unverified X-Actor: "alice")`},
+		{strings.Repeat("a", 100), `192.0.2.1:1234 (unverified X-Actor: "` + strings.Repeat("a", maxActorHeaderLength) + `")`},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.RemoteAddr = "192.0.2.1:1234"
+		if tt.header != "" {
+			r.Header.Set(actorHeader, tt.header)
+		}
+		if got := requestActor(r); got != tt.want {
+			t.Errorf("requestActor() with header %q = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeRecipient(t *testing.T) {
+	a := New(&config.Config{DefaultCountryCode: "90"}, nil, nil, nil, nil)
+
+	tests := []struct {
+		channel   models.MessageChannel
+		recipient string
+		want      string
+		wantErr   bool
+	}{
+		{models.MessageChannelSMS, "0555 123 45 67", "+905551234567", false},
+		{"", "0555 123 45 67", "+905551234567", false},
+		{"", " user@example.com ", "user@example.com", false},
+		{models.MessageChannelEmail, "user@example.com", "user@example.com", false},
+		{models.MessageChannelPush, " device-token-1 ", "device-token-1", false},
+		{models.MessageChannelChat, "C024BE91L", "C024BE91L", false},
+		{models.MessageChannelSMS, "device-token-1", "", true},
+		{models.MessageChannelPush, " ", "", true},
+		{"fax", "+905551234567", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := a.normalizeRecipient(tt.channel, tt.recipient)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("normalizeRecipient(%q, %q) = %q, %v, want %q, error %v", tt.channel, tt.recipient, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestVerifyInboundSignature(t *testing.T) {
+	secret := "shared-secret"
+	body := []byte(`{"from": "+905551234567", "text": "STOP"}`)
+	now := time.Now()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		want      bool
+	}{
+		{"valid", secret, timestamp, notification.Sign([]byte(secret), timestamp, body), true},
+		{"no secret configured", "", timestamp, notification.Sign([]byte(""), timestamp, body), false},
+		{"missing signature", secret, timestamp, "", false},
+		{"wrong secret", secret, timestamp, notification.Sign([]byte("other"), timestamp, body), false},
+		{"stale timestamp", secret, "1", notification.Sign([]byte(secret), "1", body), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := New(&config.Config{InboundWebhookSecret: tt.secret}, nil, nil, nil, nil)
+			r := httptest.NewRequest(http.MethodPost, "/webhooks/inbound", nil)
+			r.Header.Set(notification.DefaultTimestampHeader, tt.timestamp)
+			r.Header.Set(notification.DefaultSignatureHeader, tt.signature)
+			if got := a.verifyInboundSignature(r, body, now); got != tt.want {
+				t.Errorf("verifyInboundSignature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReceiveInboundMessage_RejectsUnsignedRequests(t *testing.T) {
+	a := New(&config.Config{InboundWebhookSecret: "shared-secret"}, nil, nil, nil, nil)
+	r := httptest.NewRequest(http.MethodPost, "/webhooks/inbound", strings.NewReader(`{"from": "+905551234567", "text": "START"}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	a.ReceiveInboundMessage(w, r)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("ReceiveInboundMessage() status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,322 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	"github.com/mehmetalisavas/message-sender/internal/models"
+	"github.com/mehmetalisavas/message-sender/pkg/messagetemplate"
+)
+
+// maxCampaignRecipients is the largest number of recipients accepted by a single request.
+const maxCampaignRecipients = 10000
+
+// campaignActions maps the campaign actions of the API to the status they move the campaign to.
+var campaignActions = map[string]models.CampaignStatus{
+	"start":  models.CampaignStatusRunning,
+	"pause":  models.CampaignStatusPaused,
+	"cancel": models.CampaignStatusCancelled,
+}
+
+// CampaignRequest represents the payload to create a campaign.
+// Either the content or a template is sent to the recipients.
+type CampaignRequest struct {
+	Name       string                `json:"name"`
+	Channel    models.MessageChannel `json:"channel"`
+	Content    string                `json:"content"`
+	TemplateID *int                  `json:"template_id"`
+	// TemplateVersion defaults to the latest version of the template.
+	TemplateVersion int    `json:"template_version"`
+	Locale          string `json:"locale"`
+}
+
+// CampaignRecipient is a recipient of a campaign with the variables of the campaign's template.
+type CampaignRecipient struct {
+	Recipient string                 `json:"recipient"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// CampaignRecipientsRequest represents the payload to add recipients to a campaign.
+type CampaignRecipientsRequest struct {
+	Recipients []CampaignRecipient `json:"recipients"`
+}
+
+// RejectedRecipient is a recipient that couldn't be added to a campaign.
+type RejectedRecipient struct {
+	Recipient string `json:"recipient"`
+	Error     string `json:"error"`
+}
+
+// CampaignRecipientsResponse tells how many recipients were added to a campaign.
+type CampaignRecipientsResponse struct {
+	Added    int                 `json:"added"`
+	Rejected []RejectedRecipient `json:"rejected"`
+}
+
+// CampaignResponse represents a campaign with the live number of its messages per status.
+type CampaignResponse struct {
+	models.Campaign
+	Total  int                          `json:"total"`
+	Counts map[models.MessageStatus]int `json:"counts"`
+}
+
+// CreateCampaign handles creating a draft campaign
+// @Summary Create a campaign
+// @Description Create a draft campaign sending either the content or a template to its recipients. The template version is pinned on creation
+// @Accept json
+// @Produce json
+// @Param request body CampaignRequest true "Campaign"
+// @Success 201 {object} models.Campaign "Created campaign"
+// @Failure 400 {string} string "Invalid campaign"
+// @Failure 500 {string} string "Internal server error"
+// @Router /campaigns [post]
+func (a *Api) CreateCampaign(w http.ResponseWriter, r *http.Request) {
+	var req CampaignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	c := models.Campaign{
+		Name:    req.Name,
+		Channel: req.Channel,
+		Content: req.Content,
+		Locale:  req.Locale,
+	}
+	if c.Channel == "" {
+		c.Channel = models.MessageChannelSMS
+	}
+	if c.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	if !c.Channel.Valid() {
+		http.Error(w, fmt.Sprintf("invalid channel %q", c.Channel), http.StatusBadRequest)
+		return
+	}
+	if (c.Content == "") == (req.TemplateID == nil) {
+		http.Error(w, "either content or template_id is required", http.StatusBadRequest)
+		return
+	}
+	if utf8.RuneCountInString(c.Content) > models.MaxContentLength {
+		http.Error(w, fmt.Sprintf("content exceeds %d characters", models.MaxContentLength), http.StatusBadRequest)
+		return
+	}
+
+	if req.TemplateID != nil {
+		t, err := a.storageService.GetTemplate(r.Context(), *req.TemplateID, req.TemplateVersion)
+		if errors.Is(err, models.ErrNotFound) {
+			http.Error(w, "template not found", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if _, ok := t.Body(c.Locale); !ok {
+			http.Error(w, fmt.Sprintf("template has no body for locale %q", c.Locale), http.StatusBadRequest)
+			return
+		}
+		c.TemplateID = &t.ID
+		c.TemplateVersion = t.Version
+	}
+
+	created, err := a.storageService.CreateCampaign(r.Context(), c)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// ListCampaigns handles listing the campaigns with optional pagination
+// @Summary List campaigns
+// @Description Get a list of campaigns with optional pagination parameters (limit, offset, page)
+// @Param limit query int false "Limit of campaigns to return"
+// @Param offset query int false "Offset for pagination"
+// @Param page query int false "Page number"
+// @Success 200 {array} models.Campaign "List of campaigns"
+// @Failure 500 {string} string "Internal server error"
+// @Router /campaigns [get]
+func (a *Api) ListCampaigns(w http.ResponseWriter, r *http.Request) {
+	campaigns, err := a.storageService.ListCampaigns(r.Context(), listOptionsFromRequest(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(campaigns)
+}
+
+// GetCampaign handles returning a campaign with its progress
+// @Summary Get a campaign
+// @Description Get the campaign with the live number of its messages per status
+// @Param id path int true "Campaign ID"
+// @Success 200 {object} CampaignResponse "Campaign with its progress"
+// @Failure 404 {string} string "Campaign not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /campaigns/{id} [get]
+func (a *Api) GetCampaign(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+	c, err := a.storageService.GetCampaign(r.Context(), id)
+	if errors.Is(err, models.ErrNotFound) {
+		http.Error(w, "campaign not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	counts, err := a.storageService.GetCampaignStats(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := CampaignResponse{Campaign: *c, Counts: counts}
+	for _, count := range counts {
+		resp.Total += count
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// AddCampaignRecipients handles adding recipients to a campaign
+// @Summary Add campaign recipients
+// @Description Create a pending message of the campaign for every valid recipient, invalid recipients are reported back. Messages are only sent while the campaign is running
+// @Accept json
+// @Produce json
+// @Param id path int true "Campaign ID"
+// @Param request body CampaignRecipientsRequest true "Recipients"
+// @Success 201 {object} CampaignRecipientsResponse "Added and rejected recipients"
+// @Failure 400 {string} string "Invalid recipients"
+// @Failure 404 {string} string "Campaign not found"
+// @Failure 409 {string} string "Campaign is cancelled"
+// @Failure 500 {string} string "Internal server error"
+// @Router /campaigns/{id}/recipients [post]
+func (a *Api) AddCampaignRecipients(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+	var req CampaignRecipientsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(req.Recipients) == 0 || len(req.Recipients) > maxCampaignRecipients {
+		http.Error(w, fmt.Sprintf("between 1 and %d recipients are required", maxCampaignRecipients), http.StatusBadRequest)
+		return
+	}
+
+	c, err := a.storageService.GetCampaign(r.Context(), id)
+	if errors.Is(err, models.ErrNotFound) {
+		http.Error(w, "campaign not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	body := ""
+	if c.TemplateID != nil {
+		t, err := a.storageService.GetTemplate(r.Context(), *c.TemplateID, c.TemplateVersion)
+		if errors.Is(err, models.ErrNotFound) {
+			http.Error(w, "template of the campaign not found", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		body, _ = t.Body(c.Locale)
+	}
+
+	resp := CampaignRecipientsResponse{Rejected: []RejectedRecipient{}}
+	messages := make([]models.Message, 0, len(req.Recipients))
+	policy := a.smsPolicy()
+	for _, recipient := range req.Recipients {
+		var (
+			m   models.Message
+			err error
+		)
+		content := c.Content
+		if c.TemplateID != nil {
+			content, err = messagetemplate.Render(body, recipient.Variables)
+		}
+		if err == nil {
+			m, err = CreateMessageRequest{Recipient: recipient.Recipient, Content: content, Channel: c.Channel}.toMessage(policy)
+		}
+		if err != nil {
+			resp.Rejected = append(resp.Rejected, RejectedRecipient{Recipient: recipient.Recipient, Error: err.Error()})
+			continue
+		}
+		messages = append(messages, m)
+	}
+
+	if len(messages) > 0 {
+		err = a.storageService.AddCampaignMessages(r.Context(), id, messages, requestActor(r))
+		if errors.Is(err, models.ErrCampaignCancelled) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	resp.Added = len(messages)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// UpdateCampaignStatus handles starting, pausing and cancelling a campaign
+// @Summary Start, pause or cancel a campaign
+// @Description start sends the pending messages of a draft or paused campaign, pause keeps them pending and cancel cancels them for good
+// @Produce json
+// @Param id path int true "Campaign ID"
+// @Param action path string true "Action: start, pause or cancel"
+// @Param X-Actor header string false "Unverified identity of the caller, recorded next to its remote address in the events of the cancelled messages"
+// @Success 200 {object} models.Campaign "Updated campaign"
+// @Failure 404 {string} string "Campaign not found"
+// @Failure 409 {string} string "Invalid campaign status transition"
+// @Failure 500 {string} string "Internal server error"
+// @Router /campaigns/{id}/{action} [post]
+func (a *Api) UpdateCampaignStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, _ := strconv.Atoi(vars["id"])
+	status := campaignActions[vars["action"]]
+
+	c, err := a.storageService.SetCampaignStatus(r.Context(), id, status, requestActor(r))
+	if errors.Is(err, models.ErrNotFound) {
+		http.Error(w, "campaign not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, models.ErrInvalidCampaignTransition) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(c)
+}
@@ -2,10 +2,18 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/gorilla/mux"
 	"github.com/mehmetalisavas/message-sender/internal/models"
+	"github.com/mehmetalisavas/message-sender/internal/processing"
+	"github.com/mehmetalisavas/message-sender/pkg/messagetemplate"
 )
 
 // ListSentMessages handles listing the sent messages with optional pagination
@@ -18,59 +26,306 @@ import (
 // @Failure 500 {string} string "Internal server error"
 // @Router /messages [get]
 func (a *Api) ListSentMessages(w http.ResponseWriter, r *http.Request) {
-	opts := models.ListOptions{}
+	opts := listOptionsFromRequest(r)
 
-	limit := r.URL.Query().Get("limit")
-	if limit != "" {
-		opts.Limit, _ = strconv.Atoi(limit)
+	messages, err := a.storageService.ListSentMessages(r.Context(), opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	offset := r.URL.Query().Get("offset")
-	if offset != "" {
-		opts.Offset, _ = strconv.Atoi(offset)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(messages)
+}
+
+// CreateMessageRequest represents the payload to create a message.
+// The content is either given or rendered from a template.
+type CreateMessageRequest struct {
+	Recipient string                `json:"recipient"`
+	Content   string                `json:"content"`
+	Channel   models.MessageChannel `json:"channel"`
+
+	TemplateID int `json:"template_id"`
+	// TemplateVersion defaults to the latest version of the template.
+	TemplateVersion int                    `json:"template_version"`
+	Variables       map[string]interface{} `json:"variables"`
+	// Locale falls back to the language and then to the default locale of the template.
+	Locale string `json:"locale"`
+}
+
+// toMessage validates the request and converts it to a message.
+// SMS are normalized and checked according to the policy.
+func (req CreateMessageRequest) toMessage(policy smsPolicy) (models.Message, error) {
+	m := models.Message{
+		Recipient: strings.TrimSpace(req.Recipient),
+		Content:   req.Content,
+		Channel:   req.Channel,
+	}
+	if m.Channel == "" {
+		m.Channel = models.MessageChannelSMS
 	}
 
-	page := r.URL.Query().Get("page")
-	if page != "" {
-		opts.Page, _ = strconv.Atoi(page)
+	if !m.Channel.Valid() {
+		return m, fmt.Errorf("invalid channel %q", m.Channel)
+	}
+	if m.Recipient == "" {
+		return m, errors.New("recipient is required")
+	}
+	if m.Content == "" {
+		return m, errors.New("content is required")
+	}
+	if utf8.RuneCountInString(m.Content) > models.MaxContentLength {
+		return m, fmt.Errorf("content exceeds %d characters", models.MaxContentLength)
 	}
 
-	messages, err := a.storageService.ListSentMessages(r.Context(), opts)
+	if m.Channel == models.MessageChannelSMS {
+		recipient, analysis, err := policy.apply(m.Recipient, m.Content)
+		if err != nil {
+			return m, err
+		}
+		m.Recipient = recipient
+		m.Segments = analysis.Segments
+	}
+
+	return m, nil
+}
+
+// CreateMessage handles creating a pending message
+// @Summary Create a message
+// @Description Enqueue a pending message, SMS recipients are normalized to E.164 and the content is limited to MAX_SMS_SEGMENTS segments. The content can be rendered from a template with variables and a locale instead
+// @Accept json
+// @Produce json
+// @Param request body CreateMessageRequest true "Message"
+// @Success 201 {object} models.Message "Created message"
+// @Failure 400 {string} string "Invalid message"
+// @Failure 500 {string} string "Internal server error"
+// @Router /messages [post]
+func (a *Api) CreateMessage(w http.ResponseWriter, r *http.Request) {
+	var req CreateMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.TemplateID != 0 {
+		if req.Content != "" {
+			http.Error(w, "content and template_id can't be used together", http.StatusBadRequest)
+			return
+		}
+
+		t, err := a.storageService.GetTemplate(r.Context(), req.TemplateID, req.TemplateVersion)
+		if errors.Is(err, models.ErrNotFound) {
+			http.Error(w, "template not found", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		body, ok := t.Body(req.Locale)
+		if !ok {
+			http.Error(w, fmt.Sprintf("template has no body for locale %q", req.Locale), http.StatusBadRequest)
+			return
+		}
+		req.Content, err = messagetemplate.Render(body, req.Variables)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	m, err := req.toMessage(a.smsPolicy())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	created, err := a.storageService.CreateMessage(r.Context(), m, requestActor(r))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(messages)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// CancelMessage handles cancelling a pending message
+// @Summary Cancel a pending message
+// @Description Cancel the message unless it is already picked up, the cancellation is recorded in its status timeline
+// @Param id path int true "Message ID"
+// @Param X-Actor header string false "Unverified identity of the caller, recorded next to its remote address in the status timeline"
+// @Success 204 "Message cancelled"
+// @Failure 404 {string} string "Message not found"
+// @Failure 409 {string} string "Message is not pending"
+// @Failure 500 {string} string "Internal server error"
+// @Router /messages/{id}/cancel [post]
+func (a *Api) CancelMessage(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+	err := a.storageService.UpdateMessageStatus(r.Context(), id, models.MessageStatusPending, models.MessageStatusCancelled, requestActor(r), "cancelled through the API")
+	var conflict *models.StatusConflictError
+	if errors.As(err, &conflict) {
+		http.Error(w, fmt.Sprintf("message is %s, only pending messages can be cancelled", conflict.Actual), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, models.ErrNotFound) {
+		http.Error(w, "message not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ProcessingCommandRequest represents the payload of a message processing command.
+type ProcessingCommandRequest struct {
+	Command models.ProcessingCommand `json:"command"`
 }
 
-// UpdateMessageProcessing handles the command to start or stop message processing
+// UpdateMessageProcessing handles the commands that control message processing
 // @Summary Update message processing state
-// @Description Start or stop the message processing based on the command (start/stop)
-// @Param command query string true "Command: start or stop"
-// @Success 200 {string} string "Message processing started or stopped"
+// @Description Control the message processing on all instances. start and stop take effect immediately, pause-after-current stops after the current batch is dispatched and run-once stops every instance, only the instance receiving the command dispatches a single batch before stopping
+// @Accept json
+// @Param command query string false "Command: start, stop, pause-after-current or run-once"
+// @Param request body ProcessingCommandRequest false "Command payload, alternative to the query parameter"
+// @Param X-Actor header string false "Unverified identity of the caller, recorded next to its remote address in the state history"
+// @Success 200 {string} string "Message processing started, stopped or paused"
 // @Failure 400 {string} string "Command is required or invalid command"
-// @Router /process_message [get]
+// @Failure 500 {string} string "Internal server error"
+// @Router /process_message [post]
 func (a *Api) UpdateMessageProcessing(w http.ResponseWriter, r *http.Request) {
-	command := r.URL.Query().Get("command")
+	req := ProcessingCommandRequest{
+		Command: models.ProcessingCommand(r.URL.Query().Get("command")),
+	}
+	if req.Command == "" && hasContentType(r, "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
 
-	if command == "" {
+	if req.Command == "" {
 		http.Error(w, "command is required", http.StatusBadRequest)
 		return
 	}
 
-	switch command {
-	case "start":
-		a.config.SetMessageProcessing(true)
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("Message processing started"))
-	case "stop":
-		a.config.SetMessageProcessing(false)
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("Message processing stopped"))
-	default:
-		http.Error(w, "invalid command", http.StatusBadRequest)
+	_, err := a.processingSyncer.Execute(r.Context(), req.Command, requestActor(r))
+	if errors.Is(err, processing.ErrInvalidCommand) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Message processing " + string(a.processingController.State())))
+}
+
+// maxRunNowBatchSize is the largest one-off batch size accepted by the run-now trigger.
+const maxRunNowBatchSize = 1000
+
+// RunMessageProcessingNow handles triggering the producer to fetch and dispatch pending messages immediately
+// @Summary Trigger message processing now
+// @Description Make the producer fetch and dispatch pending messages immediately without waiting for the next tick. Concurrent triggers are coalesced
+// @Param batch_size query int false "One-off number of messages to fetch, defaults to the regular batch size"
+// @Success 202 {string} string "Message processing triggered"
+// @Failure 400 {string} string "Invalid batch size"
+// @Failure 409 {string} string "Message processing is not started"
+// @Router /process_message/run_now [post]
+func (a *Api) RunMessageProcessingNow(w http.ResponseWriter, r *http.Request) {
+	batchSize := 0
+	if value := r.URL.Query().Get("batch_size"); value != "" {
+		var err error
+		batchSize, err = strconv.Atoi(value)
+		if err != nil || batchSize <= 0 || batchSize > maxRunNowBatchSize {
+			http.Error(w, fmt.Sprintf("batch_size must be between 1 and %d", maxRunNowBatchSize), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if state := a.processingController.State(); state != models.ProcessingStateStarted {
+		http.Error(w, fmt.Sprintf("message processing is %s", state), http.StatusConflict)
+		return
+	}
+
+	a.processingController.TriggerRun(batchSize)
+
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("Message processing triggered"))
+}
+
+// ProcessingStatusResponse represents the current processing state and its change history.
+type ProcessingStatusResponse struct {
+	State     models.ProcessingState         `json:"state"`
+	ChangedBy string                         `json:"changed_by"`
+	ChangedAt time.Time                      `json:"changed_at"`
+	History   []models.ProcessingStateChange `json:"history"`
+}
+
+// GetMessageProcessingStatus handles returning the cluster-wide message processing state
+// @Summary Get message processing state
+// @Description Get the current message processing state and the history of changes with optional pagination parameters (limit, offset, page)
+// @Param limit query int false "Limit of history entries to return"
+// @Param offset query int false "Offset for pagination"
+// @Param page query int false "Page number"
+// @Success 200 {object} ProcessingStatusResponse "Current state and history"
+// @Failure 404 {string} string "Processing state not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /process_message/status [get]
+func (a *Api) GetMessageProcessingStatus(w http.ResponseWriter, r *http.Request) {
+	current, err := a.storageService.GetProcessingState(r.Context())
+	if errors.Is(err, models.ErrNotFound) {
+		http.Error(w, "processing state not found", http.StatusNotFound)
+		return
 	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	history, err := a.storageService.ListProcessingStateChanges(r.Context(), listOptionsFromRequest(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ProcessingStatusResponse{
+		State:     current.State,
+		ChangedBy: current.ChangedBy,
+		ChangedAt: current.ChangedAt,
+		History:   history,
+	})
+}
+
+// listOptionsFromRequest parses the pagination parameters (limit, offset, page) of the request.
+func listOptionsFromRequest(r *http.Request) models.ListOptions {
+	opts := models.ListOptions{}
+
+	limit := r.URL.Query().Get("limit")
+	if limit != "" {
+		opts.Limit, _ = strconv.Atoi(limit)
+	}
+
+	offset := r.URL.Query().Get("offset")
+	if offset != "" {
+		opts.Offset, _ = strconv.Atoi(offset)
+	}
+
+	page := r.URL.Query().Get("page")
+	if page != "" {
+		opts.Page, _ = strconv.Atoi(page)
+	}
+
+	return opts
 }
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// ListMessageEvents handles returning the status timeline of a message
+// @Summary Get the status timeline of a message
+// @Description Get every status transition of the message, oldest first, with who made it and why
+// @Produce json
+// @Param id path int true "Message ID"
+// @Success 200 {array} models.MessageEvent "Status transitions of the message"
+// @Failure 500 {string} string "Internal server error"
+// @Router /messages/{id}/events [get]
+func (a *Api) ListMessageEvents(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+	events, err := a.storageService.ListMessageEvents(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(events)
+}
+
+// ListMessageEventsBetween handles listing the status transitions of all messages in a time window
+// @Summary List message status transitions in a time window
+// @Description Get the status transitions of all messages made in [from, to), oldest first, with optional pagination parameters (limit, offset, page)
+// @Produce json
+// @Param from query string true "Start of the window, RFC 3339"
+// @Param to query string false "End of the window, RFC 3339, defaults to now"
+// @Param limit query int false "Limit of events to return"
+// @Param offset query int false "Offset for pagination"
+// @Param page query int false "Page number"
+// @Success 200 {array} models.MessageEvent "Status transitions"
+// @Failure 400 {string} string "Invalid time window"
+// @Failure 500 {string} string "Internal server error"
+// @Router /message_events [get]
+func (a *Api) ListMessageEventsBetween(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	from, err := time.Parse(time.RFC3339, query.Get("from"))
+	if err != nil {
+		http.Error(w, "from must be an RFC 3339 time", http.StatusBadRequest)
+		return
+	}
+	to := time.Now()
+	if query.Get("to") != "" {
+		to, err = time.Parse(time.RFC3339, query.Get("to"))
+		if err != nil {
+			http.Error(w, "to must be an RFC 3339 time", http.StatusBadRequest)
+			return
+		}
+	}
+	if !from.Before(to) {
+		http.Error(w, "from must be before to", http.StatusBadRequest)
+		return
+	}
+
+	events, err := a.storageService.ListMessageEventsBetween(r.Context(), from, to, listOptionsFromRequest(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(events)
+}
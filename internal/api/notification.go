@@ -0,0 +1,24 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/mehmetalisavas/message-sender/pkg/services/notification"
+)
+
+// ListCircuitBreakers handles listing the state of the notification circuit breakers
+// @Summary List circuit breakers
+// @Description Get the state of the circuit breakers around the notification senders and providers
+// @Success 200 {array} notification.BreakerStatus "List of circuit breakers"
+// @Router /circuit_breakers [get]
+func (a *Api) ListCircuitBreakers(w http.ResponseWriter, r *http.Request) {
+	statuses := make([]notification.BreakerStatus, 0, len(a.circuitBreakers))
+	for _, breaker := range a.circuitBreakers {
+		statuses = append(statuses, breaker.Status())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(statuses)
+}
@@ -0,0 +1,261 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mehmetalisavas/message-sender/internal/models"
+	"github.com/mehmetalisavas/message-sender/internal/schedule"
+)
+
+// ScheduleRequest represents the payload to create or update a recurring schedule.
+// Either the content or a template is sent at every fire time.
+type ScheduleRequest struct {
+	Name           string `json:"name"`
+	CronExpression string `json:"cron_expression"`
+	Timezone       string `json:"timezone"`
+	Recipient      string `json:"recipient"`
+	Content        string `json:"content"`
+	TemplateID     *int   `json:"template_id"`
+	// TemplateVersion defaults to the latest version of the template.
+	TemplateVersion int                    `json:"template_version"`
+	Variables       map[string]interface{} `json:"variables"`
+	Locale          string                 `json:"locale"`
+	Enabled         *bool                  `json:"enabled"`
+}
+
+// toSchedule validates the request and converts it to a schedule with its next run time.
+// The template is the one referred by the request, its version is pinned and it is rendered once
+// to check the variables. The schedule's SMS is normalized and checked according to the policy.
+func (req ScheduleRequest) toSchedule(policy smsPolicy, t *models.Template) (models.Schedule, error) {
+	s := models.Schedule{
+		Name:           req.Name,
+		CronExpression: req.CronExpression,
+		Timezone:       req.Timezone,
+		Recipient:      req.Recipient,
+		Content:        req.Content,
+		TemplateID:     req.TemplateID,
+		Enabled:        true,
+	}
+	if s.Timezone == "" {
+		s.Timezone = "UTC"
+	}
+	if req.Enabled != nil {
+		s.Enabled = *req.Enabled
+	}
+
+	if err := schedule.Validate(s); err != nil {
+		return s, err
+	}
+
+	content := s.Content
+	if t != nil {
+		s.TemplateID = &t.ID
+		s.TemplateVersion = t.Version
+		s.Locale = req.Locale
+		s.Variables = req.Variables
+
+		var err error
+		content, err = schedule.Render(*t, s)
+		if err != nil {
+			return s, err
+		}
+	}
+
+	recipient, _, err := policy.apply(s.Recipient, content)
+	if err != nil {
+		return s, err
+	}
+	s.Recipient = recipient
+
+	if s.Enabled {
+		nextRunAt, err := schedule.NextRun(s, time.Now())
+		if err != nil {
+			return s, err
+		}
+		s.NextRunAt = nextRunAt
+	}
+
+	return s, nil
+}
+
+// scheduleTemplate returns the template referred by the request, it is nil for content schedules.
+// It writes the error response and returns false if the template can't be loaded.
+func (a *Api) scheduleTemplate(w http.ResponseWriter, r *http.Request, req ScheduleRequest) (*models.Template, bool) {
+	if req.TemplateID == nil {
+		return nil, true
+	}
+
+	t, err := a.storageService.GetTemplate(r.Context(), *req.TemplateID, req.TemplateVersion)
+	if errors.Is(err, models.ErrNotFound) {
+		http.Error(w, "template not found", http.StatusBadRequest)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return t, true
+}
+
+// CreateSchedule handles creating a recurring schedule
+// @Summary Create a recurring schedule
+// @Description Create a schedule that enqueues a pending message at every fire time of the cron expression, with either the content or a template rendered with the variables. The template version is pinned when the schedule is saved
+// @Accept json
+// @Produce json
+// @Param request body ScheduleRequest true "Schedule definition"
+// @Success 201 {object} models.Schedule "Created schedule"
+// @Failure 400 {string} string "Invalid schedule"
+// @Failure 500 {string} string "Internal server error"
+// @Router /schedules [post]
+func (a *Api) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	var req ScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	t, ok := a.scheduleTemplate(w, r, req)
+	if !ok {
+		return
+	}
+
+	s, err := req.toSchedule(a.smsPolicy(), t)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	created, err := a.storageService.CreateSchedule(r.Context(), s)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// ListSchedules handles listing the recurring schedules with optional pagination
+// @Summary List recurring schedules
+// @Description Get a list of recurring schedules with optional pagination parameters (limit, offset, page)
+// @Param limit query int false "Limit of schedules to return"
+// @Param offset query int false "Offset for pagination"
+// @Param page query int false "Page number"
+// @Success 200 {array} models.Schedule "List of schedules"
+// @Failure 500 {string} string "Internal server error"
+// @Router /schedules [get]
+func (a *Api) ListSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules, err := a.storageService.ListSchedules(r.Context(), listOptionsFromRequest(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(schedules)
+}
+
+// GetSchedule handles returning a single recurring schedule
+// @Summary Get a recurring schedule
+// @Param id path int true "Schedule ID"
+// @Success 200 {object} models.Schedule "Schedule"
+// @Failure 404 {string} string "Schedule not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /schedules/{id} [get]
+func (a *Api) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+	s, err := a.storageService.GetSchedule(r.Context(), id)
+	if errors.Is(err, models.ErrNotFound) {
+		http.Error(w, "schedule not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(s)
+}
+
+// UpdateSchedule handles replacing the definition of a recurring schedule
+// @Summary Update a recurring schedule
+// @Description Replace the schedule definition, the next run time is computed from now
+// @Accept json
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Param request body ScheduleRequest true "Schedule definition"
+// @Success 200 {object} models.Schedule "Updated schedule"
+// @Failure 400 {string} string "Invalid schedule"
+// @Failure 404 {string} string "Schedule not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /schedules/{id} [put]
+func (a *Api) UpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+	var req ScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	t, ok := a.scheduleTemplate(w, r, req)
+	if !ok {
+		return
+	}
+
+	s, err := req.toSchedule(a.smsPolicy(), t)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.ID = id
+
+	updated, err := a.storageService.UpdateSchedule(r.Context(), s)
+	if errors.Is(err, models.ErrNotFound) {
+		http.Error(w, "schedule not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(updated)
+}
+
+// DeleteSchedule handles deleting a recurring schedule
+// @Summary Delete a recurring schedule
+// @Description Delete the schedule, already enqueued messages are kept
+// @Param id path int true "Schedule ID"
+// @Success 204 "Schedule deleted"
+// @Failure 404 {string} string "Schedule not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /schedules/{id} [delete]
+func (a *Api) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+	err := a.storageService.DeleteSchedule(r.Context(), id)
+	if errors.Is(err, models.ErrNotFound) {
+		http.Error(w, "schedule not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
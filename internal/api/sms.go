@@ -0,0 +1,38 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/mehmetalisavas/message-sender/pkg/phonenumber"
+	"github.com/mehmetalisavas/message-sender/pkg/smscontent"
+)
+
+// smsPolicy holds the rules SMS are created with.
+type smsPolicy struct {
+	// defaultCountryCode is used for recipients given without a country code.
+	defaultCountryCode string
+	// maxSegments is the maximum number of segments of a message, zero means no limit.
+	maxSegments int
+}
+
+func (a *Api) smsPolicy() smsPolicy {
+	return smsPolicy{
+		defaultCountryCode: a.config.DefaultCountryCode,
+		maxSegments:        a.config.MaxSMSSegments,
+	}
+}
+
+// apply normalizes the recipient to E.164 and makes sure the content fits the segment limit.
+func (p smsPolicy) apply(recipient, content string) (string, smscontent.Analysis, error) {
+	normalized, err := phonenumber.Normalize(recipient, p.defaultCountryCode)
+	if err != nil {
+		return "", smscontent.Analysis{}, err
+	}
+
+	analysis := smscontent.Analyze(content)
+	if p.maxSegments > 0 && analysis.Segments > p.maxSegments {
+		return "", analysis, fmt.Errorf("content takes %d %s segments, at most %d are allowed", analysis.Segments, analysis.Encoding, p.maxSegments)
+	}
+
+	return normalized, analysis, nil
+}
@@ -0,0 +1,254 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mehmetalisavas/message-sender/internal/models"
+	"github.com/mehmetalisavas/message-sender/pkg/phonenumber"
+	"github.com/mehmetalisavas/message-sender/pkg/services/notification"
+)
+
+const (
+	// maxInboundBodySize limits the body of inbound message requests.
+	maxInboundBodySize = 64 << 10
+	// inboundSignatureMaxAge is how old the timestamp of a signed inbound request can be, older ones are replays.
+	inboundSignatureMaxAge = 5 * time.Minute
+)
+
+// Keywords of inbound replies that opt the sender out of or back into messages.
+var (
+	optOutKeywords = map[string]bool{"STOP": true, "STOPALL": true, "UNSUBSCRIBE": true, "CANCEL": true, "END": true, "QUIT": true}
+	optInKeywords  = map[string]bool{"START": true, "UNSTOP": true, "SUBSCRIBE": true}
+)
+
+// SuppressionRequest represents the payload to add a recipient to the suppression list.
+type SuppressionRequest struct {
+	Recipient string `json:"recipient"`
+	// Channel tells how the recipient is normalized, see normalizeRecipient.
+	Channel models.MessageChannel `json:"channel"`
+	Reason  string                `json:"reason"`
+}
+
+// InboundMessageRequest represents a message received from a recipient.
+type InboundMessageRequest struct {
+	From string `json:"from"`
+	Text string `json:"text"`
+}
+
+// InboundMessageResponse tells what was done with an inbound message.
+type InboundMessageResponse struct {
+	// Action is one of opted-out, opted-in or ignored.
+	Action string `json:"action"`
+}
+
+// normalizeRecipient normalizes the recipient of the channel the way messages are normalized, so that it
+// matches the stored messages: SMS recipients to E.164, others like e-mail addresses or push tokens are
+// only trimmed. Without a channel, e-mail addresses are told apart by their @ and the rest are SMS recipients.
+func (a *Api) normalizeRecipient(channel models.MessageChannel, recipient string) (string, error) {
+	recipient = strings.TrimSpace(recipient)
+	if channel == "" {
+		channel = models.MessageChannelSMS
+		if strings.Contains(recipient, "@") {
+			channel = models.MessageChannelEmail
+		}
+	}
+
+	if !channel.Valid() {
+		return "", fmt.Errorf("invalid channel %q", channel)
+	}
+	if recipient == "" {
+		return "", errors.New("recipient is required")
+	}
+	if channel != models.MessageChannelSMS {
+		return recipient, nil
+	}
+	return phonenumber.Normalize(recipient, a.config.DefaultCountryCode)
+}
+
+// AddSuppression handles adding a recipient to the suppression list
+// @Summary Add a suppressed recipient
+// @Description Stop sending messages to the recipient, its messages are marked as suppressed instead of being sent. SMS recipients are normalized to E.164, recipients of other channels are only trimmed
+// @Accept json
+// @Produce json
+// @Param request body SuppressionRequest true "Suppressed recipient"
+// @Success 201 {object} models.Suppression "Suppression list entry"
+// @Failure 400 {string} string "Invalid recipient"
+// @Failure 500 {string} string "Internal server error"
+// @Router /suppressions [post]
+func (a *Api) AddSuppression(w http.ResponseWriter, r *http.Request) {
+	var req SuppressionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	recipient, err := a.normalizeRecipient(req.Channel, req.Recipient)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	suppression, err := a.storageService.AddSuppression(r.Context(), models.Suppression{
+		Recipient: recipient,
+		Reason:    req.Reason,
+		Source:    models.SuppressionSourceAPI,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(suppression)
+}
+
+// ListSuppressions handles listing the suppression list with optional pagination
+// @Summary List suppressed recipients
+// @Description Get the suppression list with optional pagination parameters (limit, offset, page)
+// @Param limit query int false "Limit of entries to return"
+// @Param offset query int false "Offset for pagination"
+// @Param page query int false "Page number"
+// @Success 200 {array} models.Suppression "Suppression list"
+// @Failure 500 {string} string "Internal server error"
+// @Router /suppressions [get]
+func (a *Api) ListSuppressions(w http.ResponseWriter, r *http.Request) {
+	suppressions, err := a.storageService.ListSuppressions(r.Context(), listOptionsFromRequest(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(suppressions)
+}
+
+// RemoveSuppression handles removing a recipient from the suppression list
+// @Summary Remove a suppressed recipient
+// @Param recipient path string true "Recipient"
+// @Param channel query string false "Channel of the recipient (sms, email, push or chat)"
+// @Success 204 "Recipient removed"
+// @Failure 400 {string} string "Invalid recipient"
+// @Failure 404 {string} string "Recipient is not suppressed"
+// @Failure 500 {string} string "Internal server error"
+// @Router /suppressions/{recipient} [delete]
+func (a *Api) RemoveSuppression(w http.ResponseWriter, r *http.Request) {
+	channel := models.MessageChannel(r.URL.Query().Get("channel"))
+	recipient, err := a.normalizeRecipient(channel, mux.Vars(r)["recipient"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = a.storageService.RemoveSuppression(r.Context(), recipient)
+	if errors.Is(err, models.ErrNotFound) {
+		http.Error(w, "recipient is not suppressed", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// verifyInboundSignature reports whether the request is signed with the inbound webhook secret the way
+// notification.HMACSigner signs requests, with a timestamp not older than inboundSignatureMaxAge.
+func (a *Api) verifyInboundSignature(r *http.Request, body []byte, now time.Time) bool {
+	if a.config.InboundWebhookSecret == "" {
+		return false
+	}
+
+	timestamp := r.Header.Get(notification.DefaultTimestampHeader)
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > inboundSignatureMaxAge || age < -inboundSignatureMaxAge {
+		return false
+	}
+
+	signature := r.Header.Get(notification.DefaultSignatureHeader)
+	return notification.Verify([]byte(a.config.InboundWebhookSecret), timestamp, body, signature)
+}
+
+// ReceiveInboundMessage handles the replies of recipients forwarded by the SMS provider
+// @Summary Receive an inbound message
+// @Description Replies with an opt-out keyword (STOP, STOPALL, UNSUBSCRIBE, CANCEL, END, QUIT) add the sender to the suppression list, opt-in keywords (START, UNSTOP, SUBSCRIBE) remove it unless it was added through the API. Accepts JSON or form encoded from and text fields. Requests must be signed with INBOUND_WEBHOOK_SECRET: X-Signature is the hex encoded HMAC-SHA256 of "<X-Timestamp>.<body>"
+// @Accept json
+// @Produce json
+// @Param request body InboundMessageRequest true "Inbound message"
+// @Param X-Timestamp header string true "Unix time of the signature"
+// @Param X-Signature header string true "Signature of the request"
+// @Success 200 {object} InboundMessageResponse "Action taken"
+// @Failure 400 {string} string "Invalid sender"
+// @Failure 401 {string} string "Invalid signature"
+// @Failure 500 {string} string "Internal server error"
+// @Router /webhooks/inbound [post]
+func (a *Api) ReceiveInboundMessage(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxInboundBodySize))
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !a.verifyInboundSignature(r, body, time.Now()) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var req InboundMessageRequest
+	if hasContentType(r, "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+	} else {
+		req.From = r.FormValue("from")
+		req.Text = r.FormValue("text")
+	}
+
+	from, err := a.normalizeRecipient(models.MessageChannelSMS, req.From)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := InboundMessageResponse{Action: "ignored"}
+	keyword := strings.ToUpper(strings.TrimSpace(req.Text))
+	switch {
+	case optOutKeywords[keyword]:
+		_, err = a.storageService.AddSuppression(r.Context(), models.Suppression{
+			Recipient: from,
+			Reason:    keyword,
+			Source:    models.SuppressionSourceKeyword,
+		})
+		resp.Action = "opted-out"
+	case optInKeywords[keyword]:
+		// Only an opt-out by keyword can be undone by keyword, entries added through the API are kept.
+		err = a.storageService.RemoveSuppressionWithSource(r.Context(), from, models.SuppressionSourceKeyword)
+		if errors.Is(err, models.ErrNotFound) {
+			err = nil
+		}
+		resp.Action = "opted-in"
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
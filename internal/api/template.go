@@ -0,0 +1,234 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/mehmetalisavas/message-sender/internal/models"
+	"github.com/mehmetalisavas/message-sender/pkg/messagetemplate"
+)
+
+// TemplateRequest represents the payload to create a template.
+type TemplateRequest struct {
+	Name string `json:"name"`
+	// DefaultLocale can be omitted when there is a single body.
+	DefaultLocale string `json:"default_locale"`
+	// Bodies are text/template sources keyed by locale.
+	Bodies map[string]string `json:"bodies"`
+}
+
+// TemplateVersionRequest represents the payload to create a new version of a template.
+type TemplateVersionRequest struct {
+	Bodies map[string]string `json:"bodies"`
+}
+
+// validateTemplateBodies makes sure every body parses and there is a body for the default locale.
+func validateTemplateBodies(bodies map[string]string, defaultLocale string) error {
+	if len(bodies) == 0 {
+		return errors.New("bodies are required")
+	}
+	if _, ok := bodies[defaultLocale]; !ok {
+		return fmt.Errorf("body of the default locale %q is required", defaultLocale)
+	}
+
+	for locale, body := range bodies {
+		if locale == "" {
+			return errors.New("locale of a body is empty")
+		}
+		if _, err := messagetemplate.Parse(body); err != nil {
+			return fmt.Errorf("body of locale %q: %w", locale, err)
+		}
+	}
+
+	return nil
+}
+
+// toTemplate validates the request and converts it to a template.
+func (req TemplateRequest) toTemplate() (models.Template, error) {
+	t := models.Template{
+		Name:          req.Name,
+		DefaultLocale: req.DefaultLocale,
+		Bodies:        req.Bodies,
+	}
+	if t.Name == "" {
+		return t, errors.New("name is required")
+	}
+	if t.DefaultLocale == "" && len(t.Bodies) == 1 {
+		for locale := range t.Bodies {
+			t.DefaultLocale = locale
+		}
+	}
+
+	return t, validateTemplateBodies(t.Bodies, t.DefaultLocale)
+}
+
+// CreateTemplate handles creating a message template
+// @Summary Create a message template
+// @Description Create a named template with text/template bodies per locale, stored as version 1
+// @Accept json
+// @Produce json
+// @Param request body TemplateRequest true "Template"
+// @Success 201 {object} models.Template "Created template"
+// @Failure 400 {string} string "Invalid template"
+// @Failure 409 {string} string "Template name already exists"
+// @Failure 500 {string} string "Internal server error"
+// @Router /templates [post]
+func (a *Api) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	var req TemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	t, err := req.toTemplate()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	created, err := a.storageService.CreateTemplate(r.Context(), t)
+	if errors.Is(err, models.ErrAlreadyExists) {
+		http.Error(w, "template name already exists", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// ListTemplates handles listing the message templates with optional pagination
+// @Summary List message templates
+// @Description Get a list of templates without their bodies with optional pagination parameters (limit, offset, page)
+// @Param limit query int false "Limit of templates to return"
+// @Param offset query int false "Offset for pagination"
+// @Param page query int false "Page number"
+// @Success 200 {array} models.Template "List of templates"
+// @Failure 500 {string} string "Internal server error"
+// @Router /templates [get]
+func (a *Api) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := a.storageService.ListTemplates(r.Context(), listOptionsFromRequest(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(templates)
+}
+
+// GetTemplate handles returning a message template with its bodies
+// @Summary Get a message template
+// @Param id path int true "Template ID"
+// @Param version query int false "Version of the bodies, defaults to the latest"
+// @Success 200 {object} models.Template "Template"
+// @Failure 404 {string} string "Template not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /templates/{id} [get]
+func (a *Api) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	version, _ := strconv.Atoi(r.URL.Query().Get("version"))
+
+	t, err := a.storageService.GetTemplate(r.Context(), id, version)
+	if errors.Is(err, models.ErrNotFound) {
+		http.Error(w, "template not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(t)
+}
+
+// AddTemplateVersion handles creating a new version of a message template
+// @Summary Create a new template version
+// @Description Store the bodies as the next version of the template, messages are created from the latest version by default
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param request body TemplateVersionRequest true "Bodies of the new version"
+// @Success 201 {object} models.Template "Template with the new version"
+// @Failure 400 {string} string "Invalid bodies"
+// @Failure 404 {string} string "Template not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /templates/{id}/versions [post]
+func (a *Api) AddTemplateVersion(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+	var req TemplateVersionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	current, err := a.storageService.GetTemplate(r.Context(), id, 0)
+	if errors.Is(err, models.ErrNotFound) {
+		http.Error(w, "template not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := validateTemplateBodies(req.Bodies, current.DefaultLocale); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updated, err := a.storageService.AddTemplateVersion(r.Context(), id, req.Bodies)
+	if errors.Is(err, models.ErrNotFound) {
+		http.Error(w, "template not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(updated)
+}
+
+// DeleteTemplate handles deleting a message template
+// @Summary Delete a message template
+// @Description Delete the template with all of its versions, already created messages are kept. Templates used by campaigns or schedules can't be deleted
+// @Param id path int true "Template ID"
+// @Success 204 "Template deleted"
+// @Failure 404 {string} string "Template not found"
+// @Failure 409 {string} string "Template is used by a campaign or a schedule"
+// @Failure 500 {string} string "Internal server error"
+// @Router /templates/{id} [delete]
+func (a *Api) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+	err := a.storageService.DeleteTemplate(r.Context(), id)
+	if errors.Is(err, models.ErrNotFound) {
+		http.Error(w, "template not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, models.ErrInUse) {
+		http.Error(w, "template is used by a campaign or a schedule", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
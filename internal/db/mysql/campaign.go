@@ -0,0 +1,255 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mehmetalisavas/message-sender/internal/models"
+)
+
+const campaignColumns = `id, name, channel, content, template_id, template_version, locale, status, created_at, updated_at`
+
+// campaignMessagesChunkSize is the number of messages inserted by a single statement.
+const campaignMessagesChunkSize = 500
+
+func scanCampaign(row rowScanner) (*models.Campaign, error) {
+	var (
+		c               models.Campaign
+		content, locale sql.NullString
+		templateID      sql.NullInt64
+		templateVersion sql.NullInt64
+	)
+	err := row.Scan(&c.ID, &c.Name, &c.Channel, &content, &templateID, &templateVersion, &locale, &c.Status, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	c.Content = content.String
+	c.Locale = locale.String
+	c.TemplateVersion = int(templateVersion.Int64)
+	if templateID.Valid {
+		id := int(templateID.Int64)
+		c.TemplateID = &id
+	}
+
+	return &c, nil
+}
+
+// CreateCampaign inserts a new campaign as draft.
+func (s *SqlStore) CreateCampaign(ctx context.Context, campaign models.Campaign) (*models.Campaign, error) {
+	query := `
+		INSERT INTO campaigns (name, channel, content, template_id, template_version, locale, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	content := sql.NullString{String: campaign.Content, Valid: campaign.Content != ""}
+	locale := sql.NullString{String: campaign.Locale, Valid: campaign.Locale != ""}
+	templateVersion := sql.NullInt64{Int64: int64(campaign.TemplateVersion), Valid: campaign.TemplateID != nil}
+	result, err := s.db.ExecContext(ctx, query, campaign.Name, campaign.Channel, content, campaign.TemplateID, templateVersion, locale, models.CampaignStatusDraft)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetCampaign(ctx, int(id))
+}
+
+// GetCampaign returns the campaign with the given ID.
+func (s *SqlStore) GetCampaign(ctx context.Context, id int) (*models.Campaign, error) {
+	query := `SELECT ` + campaignColumns + ` FROM campaigns WHERE id = ?`
+
+	campaign, err := scanCampaign(s.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrNotFound
+	}
+
+	return campaign, err
+}
+
+// ListCampaigns returns the campaigns according to given options, newest first.
+func (s *SqlStore) ListCampaigns(ctx context.Context, opts models.ListOptions) ([]models.Campaign, error) {
+	options := models.InitWithDefaultListOptions(opts)
+
+	query := `SELECT ` + campaignColumns + ` FROM campaigns ORDER BY id DESC LIMIT ? OFFSET ?`
+
+	rows, err := s.db.QueryContext(ctx, query, options.Limit, options.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	campaigns := make([]models.Campaign, 0, options.Limit)
+	for rows.Next() {
+		campaign, err := scanCampaign(rows)
+		if err != nil {
+			return nil, err
+		}
+		campaigns = append(campaigns, *campaign)
+	}
+
+	return campaigns, rows.Err()
+}
+
+// AddCampaignMessages inserts the messages as pending messages of the campaign with the given ID,
+// along with their creation events recorded as made by the given actor. They are only sent while
+// the campaign is running.
+func (s *SqlStore) AddCampaignMessages(ctx context.Context, campaignID int, messages []models.Message, actor string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Ensure rollback in case of any error
+
+	// Lock the campaign so that it can't be cancelled while the messages are added.
+	var status models.CampaignStatus
+	err = tx.QueryRowContext(ctx, `SELECT status FROM campaigns WHERE id = ? FOR UPDATE`, campaignID).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if status == models.CampaignStatusCancelled {
+		return models.ErrCampaignCancelled
+	}
+	if len(messages) == 0 {
+		return tx.Commit()
+	}
+
+	var firstID int64
+	for start := 0; start < len(messages); start += campaignMessagesChunkSize {
+		end := min(start+campaignMessagesChunkSize, len(messages))
+		chunk := messages[start:end]
+
+		placeholders := make([]string, len(chunk))
+		args := make([]interface{}, 0, len(chunk)*6)
+		for i, m := range chunk {
+			placeholders[i] = "(?, ?, ?, ?, ?, ?)"
+			segments := sql.NullInt64{Int64: int64(m.Segments), Valid: m.Segments > 0}
+			args = append(args, m.Content, m.Recipient, m.Channel, segments, models.MessageStatusPending, campaignID)
+		}
+
+		query := `
+			INSERT INTO messages (content, recipient, channel, segments, status, campaign_id)
+			VALUES ` + strings.Join(placeholders, ",")
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		if start == 0 {
+			if firstID, err = result.LastInsertId(); err != nil {
+				return err
+			}
+		}
+	}
+
+	// The IDs of a multi-row insert aren't necessarily consecutive, but they are all at least the
+	// first one, and the campaign lock keeps other messages from being added to it meanwhile.
+	eventQuery := `
+		INSERT INTO message_events (message_id, from_status, to_status, actor, reason)
+		SELECT id, ?, ?, ?, ?
+		FROM messages
+		WHERE campaign_id = ? AND id >= ?
+	`
+	reason := fmt.Sprintf("added to campaign %d", campaignID)
+	_, err = tx.ExecContext(ctx, eventQuery, models.MessageEventCreated, models.MessageStatusPending, actor, reason, campaignID, firstID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SetCampaignStatus moves the campaign with the given ID to the given status, if its current
+// status allows it. The pending messages of a cancelled campaign are cancelled as well,
+// recorded as changed by the given actor.
+func (s *SqlStore) SetCampaignStatus(ctx context.Context, id int, status models.CampaignStatus, changedBy string) (*models.Campaign, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() // Ensure rollback in case of any error
+
+	var current models.CampaignStatus
+	err = tx.QueryRowContext(ctx, `SELECT status FROM campaigns WHERE id = ? FOR UPDATE`, id).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !current.CanTransitionTo(status) {
+		return nil, fmt.Errorf("%w: %s to %s", models.ErrInvalidCampaignTransition, current, status)
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE campaigns SET status = ?, updated_at = NOW() WHERE id = ?`, status, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if status == models.CampaignStatusCancelled {
+		// Record the events first, INSERT ... SELECT locks the selected messages until they are cancelled.
+		eventQuery := `
+			INSERT INTO message_events (message_id, from_status, to_status, actor, reason)
+			SELECT id, ?, ?, ?, 'campaign cancelled'
+			FROM messages
+			WHERE campaign_id = ? AND status = ?
+		`
+		_, err := tx.ExecContext(ctx, eventQuery, models.MessageStatusPending, models.MessageStatusCancelled, changedBy, id, models.MessageStatusPending)
+		if err != nil {
+			return nil, err
+		}
+
+		query := `
+			UPDATE messages
+			SET status = ?, updated_at = NOW()
+			WHERE campaign_id = ? AND status = ?
+		`
+		_, err = tx.ExecContext(ctx, query, models.MessageStatusCancelled, id, models.MessageStatusPending)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetCampaign(ctx, id)
+}
+
+// GetCampaignStats returns the number of messages of the campaign with the given ID per status.
+func (s *SqlStore) GetCampaignStats(ctx context.Context, id int) (map[models.MessageStatus]int, error) {
+	query := `
+		SELECT status, COUNT(*)
+		FROM messages
+		WHERE campaign_id = ?
+		GROUP BY status
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make(map[models.MessageStatus]int)
+	for rows.Next() {
+		var (
+			status models.MessageStatus
+			count  int
+		)
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		stats[status] = count
+	}
+
+	return stats, rows.Err()
+}
@@ -0,0 +1,101 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mehmetalisavas/message-sender/internal/models"
+)
+
+func TestCampaignLifecycle(t *testing.T) {
+	ctx := context.Background()
+	store := testStorage()
+
+	campaign, err := store.CreateCampaign(ctx, models.Campaign{
+		Name:    "test campaign",
+		Channel: models.MessageChannelSMS,
+		Content: "Campaign message",
+	})
+	if err != nil {
+		t.Fatalf("CreateCampaign() error = %v", err)
+	}
+	if campaign.Status != models.CampaignStatusDraft {
+		t.Errorf("CreateCampaign() status = %s, want %s", campaign.Status, models.CampaignStatusDraft)
+	}
+
+	messages := []models.Message{
+		{Recipient: "+905555555501", Content: "Campaign message", Channel: models.MessageChannelSMS, Segments: 1},
+		{Recipient: "+905555555502", Content: "Campaign message", Channel: models.MessageChannelSMS, Segments: 1},
+	}
+	if err := store.AddCampaignMessages(ctx, campaign.ID, messages, "test"); err != nil {
+		t.Fatalf("AddCampaignMessages() error = %v", err)
+	}
+
+	stats, err := store.GetCampaignStats(ctx, campaign.ID)
+	if err != nil {
+		t.Fatalf("GetCampaignStats() error = %v", err)
+	}
+	if stats[models.MessageStatusPending] != 2 {
+		t.Errorf("GetCampaignStats() = %v, want 2 pending messages", stats)
+	}
+
+	if _, err := store.SetCampaignStatus(ctx, campaign.ID, models.CampaignStatusPaused, "test"); !errors.Is(err, models.ErrInvalidCampaignTransition) {
+		t.Errorf("SetCampaignStatus() error = %v, want %v", err, models.ErrInvalidCampaignTransition)
+	}
+
+	cancelled, err := store.SetCampaignStatus(ctx, campaign.ID, models.CampaignStatusCancelled, "test")
+	if err != nil {
+		t.Fatalf("SetCampaignStatus() error = %v", err)
+	}
+	if cancelled.Status != models.CampaignStatusCancelled {
+		t.Errorf("SetCampaignStatus() status = %s, want %s", cancelled.Status, models.CampaignStatusCancelled)
+	}
+
+	stats, err = store.GetCampaignStats(ctx, campaign.ID)
+	if err != nil {
+		t.Fatalf("GetCampaignStats() error = %v", err)
+	}
+	if stats[models.MessageStatusCancelled] != 2 {
+		t.Errorf("GetCampaignStats() = %v, want 2 cancelled messages", stats)
+	}
+
+	if err := store.AddCampaignMessages(ctx, campaign.ID, messages, "test"); !errors.Is(err, models.ErrCampaignCancelled) {
+		t.Errorf("AddCampaignMessages() error = %v, want %v", err, models.ErrCampaignCancelled)
+	}
+}
+
+func TestDeleteTemplate_UsedByCampaign(t *testing.T) {
+	ctx := context.Background()
+	store := testStorage()
+
+	template, err := store.CreateTemplate(ctx, models.Template{
+		Name:          "campaign template",
+		DefaultLocale: "en",
+		Bodies:        map[string]string{"en": "Hello {{.name}}"},
+	})
+	if err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	campaign, err := store.CreateCampaign(ctx, models.Campaign{
+		Name:            "template campaign",
+		Channel:         models.MessageChannelSMS,
+		TemplateID:      &template.ID,
+		TemplateVersion: template.Version,
+		Locale:          "en",
+	})
+	if err != nil {
+		t.Fatalf("CreateCampaign() error = %v", err)
+	}
+
+	if err := store.DeleteTemplate(ctx, template.ID); !errors.Is(err, models.ErrInUse) {
+		t.Errorf("DeleteTemplate() error = %v, want %v", err, models.ErrInUse)
+	}
+	got, err := store.GetCampaign(ctx, campaign.ID)
+	if err != nil {
+		t.Fatalf("GetCampaign() error = %v", err)
+	}
+	if got.TemplateID == nil || *got.TemplateID != template.ID {
+		t.Errorf("GetCampaign() template_id = %v, want %d", got.TemplateID, template.ID)
+	}
+}
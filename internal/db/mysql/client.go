@@ -22,6 +22,7 @@ func NewClient(cfg config.Config) (*sql.DB, error) {
 	return db, nil
 }
 
+// dsn builds the data source name of the database.
 func dsn(cfg config.Config) string {
 	return fmt.Sprintf("%s:%s@tcp(%s)/%s?parseTime=true", cfg.MysqlUser, cfg.MysqlPassword, cfg.MysqlHost, cfg.MysqlDatabase)
 }
@@ -2,18 +2,93 @@ package mysql
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/mehmetalisavas/message-sender/internal/models"
 )
 
+const messageColumns = `id, content, recipient, channel, provider, segments, campaign_id, status, created_at, updated_at`
+
+func scanMessage(row rowScanner) (*models.Message, error) {
+	var (
+		m        models.Message
+		provider sql.NullString
+		segments sql.NullInt64
+		campaign sql.NullInt64
+	)
+	err := row.Scan(&m.ID, &m.Content, &m.Recipient, &m.Channel, &provider, &segments, &campaign, &m.Status, &m.CreatedAt, &m.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	m.Provider = provider.String
+	m.Segments = int(segments.Int64)
+	m.CampaignID = int(campaign.Int64)
+
+	return &m, nil
+}
+
+// CreateMessage inserts a new pending message along with its creation event, recorded as made by the given actor.
+func (s *SqlStore) CreateMessage(ctx context.Context, message models.Message, actor string) (*models.Message, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() // Ensure rollback in case of any error
+
+	query := `
+		INSERT INTO messages (content, recipient, channel, segments, status)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	segments := sql.NullInt64{Int64: int64(message.Segments), Valid: message.Segments > 0}
+	result, err := tx.ExecContext(ctx, query, message.Content, message.Recipient, message.Channel, segments, models.MessageStatusPending)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	event := models.MessageEvent{
+		MessageID:  int(id),
+		FromStatus: models.MessageEventCreated,
+		ToStatus:   models.MessageStatusPending,
+		Actor:      actor,
+		Reason:     "created through the API",
+	}
+	if err := insertMessageEvents(ctx, tx, []models.MessageEvent{event}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.getMessage(ctx, int(id))
+}
+
+func (s *SqlStore) getMessage(ctx context.Context, id int) (*models.Message, error) {
+	query := `SELECT ` + messageColumns + ` FROM messages WHERE id = ?`
+
+	m, err := scanMessage(s.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrNotFound
+	}
+	return m, err
+}
+
 // ListSentMessages returns all sent messages according to given options.
 func (s *SqlStore) ListSentMessages(ctx context.Context, opts models.ListOptions) ([]models.Message, error) {
 	options := models.InitWithDefaultListOptions(opts)
 
 	query := `
-		SELECT id, content, recipient, status, created_at, updated_at
+		SELECT ` + messageColumns + `
 		FROM messages
 		WHERE status = ?
 		ORDER BY updated_at DESC
@@ -29,29 +104,33 @@ func (s *SqlStore) ListSentMessages(ctx context.Context, opts models.ListOptions
 	// initialize the slice with a length of 0 and a capacity of limit for better performance
 	messages := make([]models.Message, 0, options.Limit)
 	for rows.Next() {
-		var m models.Message
-		if err := rows.Scan(&m.ID, &m.Content, &m.Recipient, &m.Status, &m.CreatedAt, &m.UpdatedAt); err != nil {
+		m, err := scanMessage(rows)
+		if err != nil {
 			return nil, err
 		}
-		messages = append(messages, m)
+		messages = append(messages, *m)
 	}
 
 	return messages, nil
 }
 
-// GetPendingMessages returns pending messages from the storage in a given limit.
-func (s *SqlStore) GetPendingMessages(ctx context.Context, limit int) ([]models.Message, error) {
+// GetPendingMessages marks up to limit pending messages as processing and leases them for visibilityTimeout.
+// Processing messages whose lease expired are returned again with a new lease.
+func (s *SqlStore) GetPendingMessages(ctx context.Context, limit int, visibilityTimeout time.Duration) ([]models.Message, error) {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback() // Ensure rollback in case of any error
 
-	// Step 1: Select pending messages and lock them
+	// Step 1: Select pending messages and lock them, messages of campaigns that are not running are left pending
 	selectQuery := `
-			SELECT id, content, recipient, status, created_at, updated_at
+			SELECT ` + messageColumns + `
 			FROM messages
-			WHERE (status = 'pending' OR (status = 'processing' AND updated_at < NOW() - INTERVAL 5 MINUTE))
+			WHERE (status = 'pending' OR (status = 'processing' AND (lease_expires_at IS NULL OR lease_expires_at < NOW())))
+			AND (campaign_id IS NULL OR EXISTS (
+				SELECT 1 FROM campaigns WHERE campaigns.id = messages.campaign_id AND campaigns.status = 'running'
+			))
 			ORDER BY created_at ASC
 			LIMIT ?
 			FOR UPDATE SKIP LOCKED
@@ -65,11 +144,11 @@ func (s *SqlStore) GetPendingMessages(ctx context.Context, limit int) ([]models.
 
 	messages := make([]models.Message, 0, limit)
 	for rows.Next() {
-		var m models.Message
-		if err := rows.Scan(&m.ID, &m.Content, &m.Recipient, &m.Status, &m.CreatedAt, &m.UpdatedAt); err != nil {
+		m, err := scanMessage(rows)
+		if err != nil {
 			return nil, err
 		}
-		messages = append(messages, m)
+		messages = append(messages, *m)
 	}
 
 	// If no messages found, return early
@@ -77,29 +156,50 @@ func (s *SqlStore) GetPendingMessages(ctx context.Context, limit int) ([]models.
 		return messages, nil
 	}
 
-	placeholder := "?" // Placeholder for each id
+	// Every message gets its own lease token, so only the worker processing it can complete it.
 	placeholders := make([]string, len(messages))
+	cases := make([]string, len(messages))
+	tokens := make([]interface{}, 0, 2*len(messages))
+	ids := make([]interface{}, len(messages))
+	events := make([]models.MessageEvent, len(messages))
 	for i := range messages {
-		placeholders[i] = placeholder
+		events[i] = models.MessageEvent{
+			MessageID:  messages[i].ID,
+			FromStatus: messages[i].Status,
+			ToStatus:   models.MessageStatusProcessing,
+			Actor:      models.MessageActorProducer,
+			Reason:     "picked up",
+		}
+		if messages[i].Status == models.MessageStatusProcessing {
+			events[i].Reason = "lease expired"
+		}
+
+		messages[i].LeaseToken = uuid.NewString()
+		messages[i].Status = models.MessageStatusProcessing
+
+		placeholders[i] = "?"
+		cases[i] = "WHEN ? THEN ?"
+		tokens = append(tokens, messages[i].ID, messages[i].LeaseToken)
+		ids[i] = messages[i].ID
 	}
-	placeholderStr := strings.Join(placeholders, ",")
 
 	updateQuery := fmt.Sprintf(`
-		UPDATE messages 
-		SET status = 'processing', updated_at = NOW() 
-		WHERE id IN (%s)`, placeholderStr,
+		UPDATE messages
+		SET status = 'processing', lease_token = CASE id %s END, lease_expires_at = NOW() + INTERVAL ? SECOND, updated_at = NOW()
+		WHERE id IN (%s)`, strings.Join(cases, " "), strings.Join(placeholders, ","),
 	)
 
-	ids := make([]interface{}, len(messages))
-	for i, msg := range messages {
-		ids[i] = msg.ID
-	}
+	args := append(tokens, leaseSeconds(visibilityTimeout))
+	args = append(args, ids...)
 
 	// Run the update query to mark the messages as processing
-	_, err = tx.ExecContext(ctx, updateQuery, ids...)
+	_, err = tx.ExecContext(ctx, updateQuery, args...)
 	if err != nil {
 		return nil, err
 	}
+	if err := insertMessageEvents(ctx, tx, events); err != nil {
+		return nil, err
+	}
 
 	err = tx.Commit()
 	if err != nil {
@@ -109,16 +209,243 @@ func (s *SqlStore) GetPendingMessages(ctx context.Context, limit int) ([]models.
 	return messages, nil
 }
 
-// UpdateMessageStatus updates the status of the message with the given ID.
-func (s *SqlStore) UpdateMessageStatus(ctx context.Context, id int, status models.MessageStatus) error {
+// ExtendMessageLease extends the lease of the processing message with the given ID by visibilityTimeout.
+// It returns models.ErrLeaseLost if the message isn't leased with leaseToken anymore.
+func (s *SqlStore) ExtendMessageLease(ctx context.Context, id int, leaseToken string, visibilityTimeout time.Duration) error {
+	query := `
+		UPDATE messages
+		SET lease_expires_at = NOW() + INTERVAL ? SECOND
+		WHERE id = ? AND status = 'processing' AND lease_token = ?
+	`
+
+	result, err := s.db.ExecContext(ctx, query, leaseSeconds(visibilityTimeout), id, leaseToken)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	// No row is changed either when the lease is lost or when it is extended to the same expiry
+	// within a second, only the lease token tells them apart.
+	var held bool
+	heldQuery := `SELECT COUNT(*) > 0 FROM messages WHERE id = ? AND status = 'processing' AND lease_token = ?`
+	if err := s.db.QueryRowContext(ctx, heldQuery, id, leaseToken).Scan(&held); err != nil {
+		return err
+	}
+	if !held {
+		return models.ErrLeaseLost
+	}
+
+	return nil
+}
+
+// ReleaseMessage applies the update to the processing message leased with its token and ends the lease.
+// It returns a *models.StatusConflictError if the message isn't processing anymore, and
+// models.ErrLeaseLost if it is processed by another worker.
+func (s *SqlStore) ReleaseMessage(ctx context.Context, update models.MessageStatusUpdate) error {
+	skipped, err := s.UpdateMessageStatuses(ctx, []models.MessageStatusUpdate{update})
+	if err != nil {
+		return err
+	}
+
+	return skipped[update.ID]
+}
+
+// leaseSeconds rounds the visibility timeout up to whole seconds, leases last at least a second.
+func leaseSeconds(visibilityTimeout time.Duration) int {
+	seconds := int((visibilityTimeout + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+// UpdateMessageStatus moves the message with the given ID from status from to status to, the transition
+// is recorded with the actor and reason. It serves single transitions outside of a lease, like cancelling
+// a pending message through the API, leased messages are updated with UpdateMessageStatuses.
+// It returns models.ErrInvalidMessageTransition if the transition isn't allowed, a *models.StatusConflictError
+// with the actual status if the message isn't in status from anymore, and models.ErrNotFound if it doesn't exist.
+func (s *SqlStore) UpdateMessageStatus(ctx context.Context, id int, from, to models.MessageStatus, actor, reason string) error {
+	if !from.CanTransitionTo(to) {
+		return fmt.Errorf("%w: %s to %s", models.ErrInvalidMessageTransition, from, to)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Ensure rollback in case of any error
+
+	// Lock the message, so that its status can't change between the check and the update.
+	var actual models.MessageStatus
+	err = tx.QueryRowContext(ctx, `SELECT status FROM messages WHERE id = ? FOR UPDATE`, id).Scan(&actual)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if actual != from {
+		return &models.StatusConflictError{ID: id, Expected: from, Actual: actual}
+	}
+
 	query := `
 		UPDATE messages
 		SET status = ?, updated_at = NOW()
 		WHERE id = ?
 	`
 
-	_, err := s.db.ExecContext(ctx, query, status, id)
-	return err
+	if _, err := tx.ExecContext(ctx, query, to, id); err != nil {
+		return err
+	}
+
+	err = insertMessageEvents(ctx, tx, []models.MessageEvent{{
+		MessageID:  id,
+		FromStatus: from,
+		ToStatus:   to,
+		Actor:      actor,
+		Reason:     reason,
+	}})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateMessageStatuses applies the updates to the processing messages leased with their tokens in a
+// single statement and records them as message events. The updates that can't be applied are returned
+// keyed by message ID with the reason: a *models.StatusConflictError if the message isn't processing
+// anymore, models.ErrLeaseLost if it is leased by another worker and models.ErrNotFound if it doesn't exist.
+func (s *SqlStore) UpdateMessageStatuses(ctx context.Context, updates []models.MessageStatusUpdate) (map[int]error, error) {
+	if len(updates) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(updates))
+	ids := make([]interface{}, len(updates))
+	for i, u := range updates {
+		if !models.MessageStatusProcessing.CanTransitionTo(u.Status) {
+			return nil, fmt.Errorf("%w: %s to %s", models.ErrInvalidMessageTransition, models.MessageStatusProcessing, u.Status)
+		}
+
+		placeholders[i] = "?"
+		ids[i] = u.ID
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() // Ensure rollback in case of any error
+
+	// Lock the messages, only those still leased with the given tokens are updated.
+	selectQuery := fmt.Sprintf(`
+		SELECT id, status, lease_token
+		FROM messages
+		WHERE id IN (%s)
+		FOR UPDATE`, strings.Join(placeholders, ","),
+	)
+
+	rows, err := tx.QueryContext(ctx, selectQuery, ids...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type lease struct {
+		status models.MessageStatus
+		token  sql.NullString
+	}
+	current := make(map[int]lease, len(updates))
+	for rows.Next() {
+		var (
+			id int
+			l  lease
+		)
+		if err := rows.Scan(&id, &l.status, &l.token); err != nil {
+			return nil, err
+		}
+		current[id] = l
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	skipped := make(map[int]error)
+	leased := make(map[int]bool, len(updates))
+	for _, u := range updates {
+		l, ok := current[u.ID]
+		switch {
+		case !ok:
+			skipped[u.ID] = models.ErrNotFound
+		case l.status != models.MessageStatusProcessing:
+			skipped[u.ID] = &models.StatusConflictError{ID: u.ID, Expected: models.MessageStatusProcessing, Actual: l.status}
+		case l.token.String != u.LeaseToken:
+			skipped[u.ID] = models.ErrLeaseLost
+		default:
+			leased[u.ID] = true
+		}
+	}
+	if len(leased) == 0 {
+		return skipped, nil
+	}
+
+	statusCases := make([]string, 0, len(leased))
+	providerCases := make([]string, 0, len(leased))
+	placeholders = placeholders[:0]
+	statusArgs := make([]interface{}, 0, 2*len(leased))
+	providerArgs := make([]interface{}, 0, 2*len(leased))
+	ids = ids[:0]
+	events := make([]models.MessageEvent, 0, len(leased))
+	for _, u := range updates {
+		if !leased[u.ID] {
+			continue
+		}
+
+		statusCases = append(statusCases, "WHEN ? THEN ?")
+		providerCases = append(providerCases, "WHEN ? THEN ?")
+		placeholders = append(placeholders, "?")
+		statusArgs = append(statusArgs, u.ID, u.Status)
+		providerArgs = append(providerArgs, u.ID, sql.NullString{String: u.Provider, Valid: u.Provider != ""})
+		ids = append(ids, u.ID)
+		events = append(events, models.MessageEvent{
+			MessageID:  u.ID,
+			FromStatus: models.MessageStatusProcessing,
+			ToStatus:   u.Status,
+			Actor:      u.Actor,
+			Reason:     u.Reason,
+		})
+	}
+
+	updateQuery := fmt.Sprintf(`
+		UPDATE messages
+		SET status = CASE id %s END,
+			provider = COALESCE(CASE id %s END, provider),
+			lease_token = NULL, lease_expires_at = NULL, updated_at = NOW()
+		WHERE id IN (%s)`,
+		strings.Join(statusCases, " "), strings.Join(providerCases, " "), strings.Join(placeholders, ","),
+	)
+
+	args := append(statusArgs, providerArgs...)
+	args = append(args, ids...)
+
+	if _, err := tx.ExecContext(ctx, updateQuery, args...); err != nil {
+		return nil, err
+	}
+	if err := insertMessageEvents(ctx, tx, events); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return skipped, nil
 }
 
 // InsertTestMessages inserts a test message into the database.
@@ -139,36 +466,11 @@ func (s *SqlStore) InsertTestMessages(ctx context.Context, message models.Messag
 	}
 
 	// Fetch the full message using the inserted ID
-	var insertedMessage models.Message
-	selectQuery := `
-			SELECT id, content, recipient, status, created_at, updated_at
-			FROM messages
-			WHERE id = ?`
-
-	// Query the inserted message
-	err = s.db.QueryRowContext(ctx, selectQuery, lastInsertID).
-		Scan(&insertedMessage.ID, &insertedMessage.Content, &insertedMessage.Recipient, &insertedMessage.Status, &insertedMessage.CreatedAt, &insertedMessage.UpdatedAt)
-
-	if err != nil {
-		return nil, err
-	}
-
-	// Return the inserted message struct
-	return &insertedMessage, nil
+	return s.GetTestMessage(ctx, int(lastInsertID))
 }
 
 // GetTestMessage returns a test message from the database.
 // Don't use this function in production code.
 func (s *SqlStore) GetTestMessage(ctx context.Context, id int) (*models.Message, error) {
-	var message models.Message
-	query := `
-		SELECT id, content, recipient, status, created_at, updated_at
-		FROM messages
-		WHERE id = ?
-	`
-	err := s.db.QueryRowContext(ctx, query, id).Scan(&message.ID, &message.Content, &message.Recipient, &message.Status, &message.CreatedAt, &message.UpdatedAt)
-	if err != nil {
-		return nil, err
-	}
-	return &message, nil
+	return s.getMessage(ctx, id)
 }
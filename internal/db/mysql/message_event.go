@@ -0,0 +1,107 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/mehmetalisavas/message-sender/internal/models"
+)
+
+// maxEventReasonLength is the length of the reason column, longer reasons like error messages are truncated.
+const maxEventReasonLength = 255
+
+const messageEventColumns = `id, message_id, from_status, to_status, actor, reason, created_at`
+
+func scanMessageEvent(row rowScanner) (*models.MessageEvent, error) {
+	var (
+		e      models.MessageEvent
+		reason sql.NullString
+	)
+	err := row.Scan(&e.ID, &e.MessageID, &e.FromStatus, &e.ToStatus, &e.Actor, &reason, &e.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	e.Reason = reason.String
+
+	return &e, nil
+}
+
+// insertMessageEvents records the events within tx, so that they are only kept along with the transitions.
+func insertMessageEvents(ctx context.Context, tx *sql.Tx, events []models.MessageEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(events))
+	args := make([]interface{}, 0, len(events)*5)
+	for i, e := range events {
+		placeholders[i] = "(?, ?, ?, ?, ?)"
+		reason := sql.NullString{String: truncate(e.Reason, maxEventReasonLength), Valid: e.Reason != ""}
+		args = append(args, e.MessageID, e.FromStatus, e.ToStatus, e.Actor, reason)
+	}
+
+	query := `
+		INSERT INTO message_events (message_id, from_status, to_status, actor, reason)
+		VALUES ` + strings.Join(placeholders, ",")
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+// truncate shortens s to at most n characters.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+// ListMessageEvents returns the status transitions of the message with the given ID, oldest first.
+func (s *SqlStore) ListMessageEvents(ctx context.Context, messageID int) ([]models.MessageEvent, error) {
+	query := `
+		SELECT ` + messageEventColumns + `
+		FROM message_events
+		WHERE message_id = ?
+		ORDER BY id ASC
+	`
+
+	return s.queryMessageEvents(ctx, query, 0, messageID)
+}
+
+// ListMessageEventsBetween returns the status transitions of all messages made in [from, to),
+// oldest first, according to given options.
+func (s *SqlStore) ListMessageEventsBetween(ctx context.Context, from, to time.Time, opts models.ListOptions) ([]models.MessageEvent, error) {
+	options := models.InitWithDefaultListOptions(opts)
+
+	query := `
+		SELECT ` + messageEventColumns + `
+		FROM message_events
+		WHERE created_at >= ? AND created_at < ?
+		ORDER BY created_at ASC, id ASC
+		LIMIT ? OFFSET ?
+	`
+
+	return s.queryMessageEvents(ctx, query, options.Limit, from, to, options.Limit, options.Offset)
+}
+
+func (s *SqlStore) queryMessageEvents(ctx context.Context, query string, capacity int, args ...interface{}) ([]models.MessageEvent, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]models.MessageEvent, 0, capacity)
+	for rows.Next() {
+		e, err := scanMessageEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *e)
+	}
+
+	return events, rows.Err()
+}
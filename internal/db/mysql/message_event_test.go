@@ -0,0 +1,110 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mehmetalisavas/message-sender/internal/models"
+)
+
+func TestMessageEvents(t *testing.T) {
+	ctx := context.Background()
+	store := testStorage()
+
+	start := time.Now().Add(-time.Second)
+	inserted, err := store.insertTestMessages(ctx, models.Message{
+		Content:   "Audited Message",
+		Recipient: "+905555555555",
+		Status:    models.MessageStatusPending,
+		CreatedAt: start.Add(-time.Hour),
+		UpdatedAt: start,
+	})
+	if err != nil {
+		t.Fatalf("Failed to insert message: %v", err)
+	}
+
+	messages, err := store.GetPendingMessages(ctx, 100, time.Minute)
+	if err != nil {
+		t.Fatalf("GetPendingMessages() error = %v", err)
+	}
+	var leased *models.Message
+	for i := range messages {
+		if messages[i].ID == inserted.ID {
+			leased = &messages[i]
+		}
+	}
+	if leased == nil {
+		t.Fatalf("GetPendingMessages() didn't lease message %d", inserted.ID)
+	}
+
+	err = store.ReleaseMessage(ctx, models.MessageStatusUpdate{
+		ID:         leased.ID,
+		LeaseToken: leased.LeaseToken,
+		Status:     models.MessageStatusFailed,
+		Actor:      models.MessageActorConsumer,
+		Reason:     "provider rejected the message",
+	})
+	if err != nil {
+		t.Fatalf("ReleaseMessage() error = %v", err)
+	}
+
+	events, err := store.ListMessageEvents(ctx, inserted.ID)
+	if err != nil {
+		t.Fatalf("ListMessageEvents() error = %v", err)
+	}
+	want := []models.MessageEvent{
+		{MessageID: inserted.ID, FromStatus: models.MessageStatusPending, ToStatus: models.MessageStatusProcessing, Actor: models.MessageActorProducer, Reason: "picked up"},
+		{MessageID: inserted.ID, FromStatus: models.MessageStatusProcessing, ToStatus: models.MessageStatusFailed, Actor: models.MessageActorConsumer, Reason: "provider rejected the message"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("ListMessageEvents() returned %d events, expected %d", len(events), len(want))
+	}
+	for i := range want {
+		got := events[i]
+		if got.MessageID != want[i].MessageID || got.FromStatus != want[i].FromStatus || got.ToStatus != want[i].ToStatus ||
+			got.Actor != want[i].Actor || got.Reason != want[i].Reason {
+			t.Errorf("event %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	between, err := store.ListMessageEventsBetween(ctx, start, time.Now().Add(time.Second), models.ListOptions{Limit: 1000})
+	if err != nil {
+		t.Fatalf("ListMessageEventsBetween() error = %v", err)
+	}
+	found := 0
+	for _, e := range between {
+		if e.MessageID == inserted.ID {
+			found++
+		}
+	}
+	if found != len(want) {
+		t.Errorf("ListMessageEventsBetween() returned %d events of message %d, expected %d", found, inserted.ID, len(want))
+	}
+}
+
+func TestCreateMessage_RecordsCreationEvent(t *testing.T) {
+	ctx := context.Background()
+	store := testStorage()
+
+	created, err := store.CreateMessage(ctx, models.Message{
+		Content:   "Created Message",
+		Recipient: "+905555555555",
+		Channel:   models.MessageChannelSMS,
+		Segments:  1,
+	}, "127.0.0.1:1234")
+	if err != nil {
+		t.Fatalf("CreateMessage() error = %v", err)
+	}
+
+	events, err := store.ListMessageEvents(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("ListMessageEvents() error = %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("ListMessageEvents() returned %d events, expected 1", len(events))
+	}
+	if events[0].FromStatus != models.MessageEventCreated || events[0].ToStatus != models.MessageStatusPending || events[0].Actor != "127.0.0.1:1234" {
+		t.Errorf("creation event = %+v, want %s to %s by 127.0.0.1:1234", events[0], models.MessageEventCreated, models.MessageStatusPending)
+	}
+}
@@ -2,6 +2,7 @@ package mysql
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"testing"
@@ -159,7 +160,7 @@ func TestUpdateMessageStatus(t *testing.T) {
 	}
 
 	// Update the message status
-	err = store.UpdateMessageStatus(context.Background(), insertedMessage.ID, models.MessageStatusProcessing)
+	err = store.UpdateMessageStatus(context.Background(), insertedMessage.ID, models.MessageStatusPending, models.MessageStatusProcessing, "test", "")
 	if err != nil {
 		t.Errorf("UpdateMessageStatus() error = %v", err)
 	}
@@ -173,3 +174,173 @@ func TestUpdateMessageStatus(t *testing.T) {
 		t.Errorf("UpdateMessageStatus() failed to update message status")
 	}
 }
+
+func TestMessageLease(t *testing.T) {
+	ctx := context.Background()
+	store := testStorage()
+
+	now := time.Now()
+	inserted, err := store.insertTestMessages(ctx, models.Message{
+		Content:   "Leased Message",
+		Recipient: "+905555555555",
+		Status:    models.MessageStatusPending,
+		CreatedAt: now.Add(-time.Hour),
+		UpdatedAt: now,
+	})
+	if err != nil {
+		t.Fatalf("Failed to insert message: %v", err)
+	}
+
+	var leased *models.Message
+	messages, err := store.GetPendingMessages(ctx, 100, time.Minute)
+	if err != nil {
+		t.Fatalf("GetPendingMessages() error = %v", err)
+	}
+	for i := range messages {
+		if messages[i].ID == inserted.ID {
+			leased = &messages[i]
+		}
+	}
+	if leased == nil || leased.LeaseToken == "" {
+		t.Fatalf("GetPendingMessages() didn't lease message %d", inserted.ID)
+	}
+
+	// The message isn't reclaimed while its lease is valid.
+	messages, err = store.GetPendingMessages(ctx, 100, time.Minute)
+	if err != nil {
+		t.Fatalf("GetPendingMessages() error = %v", err)
+	}
+	for _, m := range messages {
+		if m.ID == inserted.ID {
+			t.Fatalf("GetPendingMessages() reclaimed message %d with a valid lease", inserted.ID)
+		}
+	}
+
+	if err := store.ExtendMessageLease(ctx, inserted.ID, leased.LeaseToken, time.Minute); err != nil {
+		t.Errorf("ExtendMessageLease() error = %v", err)
+	}
+	if err := store.ReleaseMessage(ctx, models.MessageStatusUpdate{ID: inserted.ID, LeaseToken: "other-token", Status: models.MessageStatusFailed}); !errors.Is(err, models.ErrLeaseLost) {
+		t.Errorf("ReleaseMessage() error = %v, want %v", err, models.ErrLeaseLost)
+	}
+	if err := store.ReleaseMessage(ctx, models.MessageStatusUpdate{ID: inserted.ID, LeaseToken: leased.LeaseToken, Status: models.MessageStatusSent}); err != nil {
+		t.Errorf("ReleaseMessage() error = %v", err)
+	}
+	if err := store.ExtendMessageLease(ctx, inserted.ID, leased.LeaseToken, time.Minute); !errors.Is(err, models.ErrLeaseLost) {
+		t.Errorf("ExtendMessageLease() error = %v, want %v", err, models.ErrLeaseLost)
+	}
+
+	fetched, err := store.getTestMessage(ctx, inserted.ID)
+	if err != nil {
+		t.Fatalf("Failed to fetch message: %v", err)
+	}
+	if fetched.Status != models.MessageStatusSent {
+		t.Errorf("ReleaseMessage() status = %s, want %s", fetched.Status, models.MessageStatusSent)
+	}
+}
+
+func TestUpdateMessageStatus_Conflict(t *testing.T) {
+	ctx := context.Background()
+	store := testStorage()
+
+	now := time.Now()
+	inserted, err := store.insertTestMessages(ctx, models.Message{
+		Content:   "Sent Message",
+		Recipient: "+905555555555",
+		Status:    models.MessageStatusSent,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		t.Fatalf("Failed to insert message: %v", err)
+	}
+
+	err = store.UpdateMessageStatus(ctx, inserted.ID, models.MessageStatusSent, models.MessageStatusFailed, "test", "")
+	if !errors.Is(err, models.ErrInvalidMessageTransition) {
+		t.Errorf("UpdateMessageStatus() error = %v, want %v", err, models.ErrInvalidMessageTransition)
+	}
+
+	// A late worker can't flip a sent message to failed.
+	err = store.ReleaseMessage(ctx, models.MessageStatusUpdate{ID: inserted.ID, LeaseToken: "late-token", Status: models.MessageStatusFailed})
+	var conflict *models.StatusConflictError
+	if !errors.As(err, &conflict) || conflict.Actual != models.MessageStatusSent {
+		t.Errorf("ReleaseMessage() error = %v, want a conflict with status %s", err, models.MessageStatusSent)
+	}
+
+	err = store.UpdateMessageStatus(ctx, inserted.ID, models.MessageStatusPending, models.MessageStatusProcessing, "test", "")
+	if !errors.As(err, &conflict) || conflict.Expected != models.MessageStatusPending || conflict.Actual != models.MessageStatusSent {
+		t.Errorf("UpdateMessageStatus() error = %v, want a conflict with status %s", err, models.MessageStatusSent)
+	}
+
+	err = store.UpdateMessageStatus(ctx, -1, models.MessageStatusPending, models.MessageStatusProcessing, "test", "")
+	if !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("UpdateMessageStatus() error = %v, want %v", err, models.ErrNotFound)
+	}
+
+	fetched, err := store.getTestMessage(ctx, inserted.ID)
+	if err != nil {
+		t.Fatalf("Failed to fetch message: %v", err)
+	}
+	if fetched.Status != models.MessageStatusSent {
+		t.Errorf("message status = %s, want %s", fetched.Status, models.MessageStatusSent)
+	}
+}
+
+func TestUpdateMessageStatuses(t *testing.T) {
+	ctx := context.Background()
+	store := testStorage()
+
+	now := time.Now()
+	for _, content := range []string{"Batched 1", "Batched 2", "Batched 3"} {
+		_, err := store.insertTestMessages(ctx, models.Message{
+			Content:   content,
+			Recipient: "+905555555555",
+			Status:    models.MessageStatusPending,
+			CreatedAt: now.Add(-time.Hour),
+			UpdatedAt: now,
+		})
+		if err != nil {
+			t.Fatalf("Failed to insert message: %v", err)
+		}
+	}
+
+	messages, err := store.GetPendingMessages(ctx, 3, time.Minute)
+	if err != nil {
+		t.Fatalf("GetPendingMessages() error = %v", err)
+	}
+	if len(messages) != 3 {
+		t.Fatalf("GetPendingMessages() returned %d messages, expected 3", len(messages))
+	}
+
+	updates := []models.MessageStatusUpdate{
+		{ID: messages[0].ID, LeaseToken: messages[0].LeaseToken, Status: models.MessageStatusSent, Provider: "primary"},
+		{ID: messages[1].ID, LeaseToken: messages[1].LeaseToken, Status: models.MessageStatusFailed},
+		// The lease of the last message is held by another worker.
+		{ID: messages[2].ID, LeaseToken: "other-token", Status: models.MessageStatusFailed},
+	}
+	skipped, err := store.UpdateMessageStatuses(ctx, updates)
+	if err != nil {
+		t.Fatalf("UpdateMessageStatuses() error = %v", err)
+	}
+	if len(skipped) != 1 || !errors.Is(skipped[messages[2].ID], models.ErrLeaseLost) {
+		t.Errorf("UpdateMessageStatuses() skipped = %v, want a lost lease of message %d", skipped, messages[2].ID)
+	}
+
+	want := []models.MessageStatus{models.MessageStatusSent, models.MessageStatusFailed, models.MessageStatusProcessing}
+	for i, m := range messages {
+		fetched, err := store.GetTestMessage(ctx, m.ID)
+		if err != nil {
+			t.Fatalf("Failed to fetch message: %v", err)
+		}
+		if fetched.Status != want[i] {
+			t.Errorf("message %d status = %s, want %s", m.ID, fetched.Status, want[i])
+		}
+	}
+
+	sent, err := store.GetTestMessage(ctx, messages[0].ID)
+	if err != nil {
+		t.Fatalf("Failed to fetch message: %v", err)
+	}
+	if sent.Provider != "primary" {
+		t.Errorf("message %d provider = %q, want primary", sent.ID, sent.Provider)
+	}
+}
@@ -0,0 +1,90 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/mehmetalisavas/message-sender/internal/models"
+)
+
+// GetProcessingState returns the latest message processing state change.
+func (s *SqlStore) GetProcessingState(ctx context.Context) (*models.ProcessingStateChange, error) {
+	query := `
+		SELECT id, state, changed_by, changed_at
+		FROM processing_states
+		ORDER BY id DESC
+		LIMIT 1
+	`
+
+	var change models.ProcessingStateChange
+	err := s.db.QueryRowContext(ctx, query).Scan(&change.ID, &change.State, &change.ChangedBy, &change.ChangedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &change, nil
+}
+
+// SetProcessingState persists a new message processing state and returns the recorded change.
+func (s *SqlStore) SetProcessingState(ctx context.Context, state models.ProcessingState, changedBy string) (*models.ProcessingStateChange, error) {
+	query := `
+		INSERT INTO processing_states (state, changed_by, changed_at)
+		VALUES (?, ?, NOW())
+	`
+
+	result, err := s.db.ExecContext(ctx, query, state, changedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	var change models.ProcessingStateChange
+	selectQuery := `
+		SELECT id, state, changed_by, changed_at
+		FROM processing_states
+		WHERE id = ?
+	`
+	err = s.db.QueryRowContext(ctx, selectQuery, id).Scan(&change.ID, &change.State, &change.ChangedBy, &change.ChangedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &change, nil
+}
+
+// ListProcessingStateChanges returns the history of message processing state changes, newest first.
+func (s *SqlStore) ListProcessingStateChanges(ctx context.Context, opts models.ListOptions) ([]models.ProcessingStateChange, error) {
+	options := models.InitWithDefaultListOptions(opts)
+
+	query := `
+		SELECT id, state, changed_by, changed_at
+		FROM processing_states
+		ORDER BY id DESC
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, options.Limit, options.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := make([]models.ProcessingStateChange, 0, options.Limit)
+	for rows.Next() {
+		var c models.ProcessingStateChange
+		if err := rows.Scan(&c.ID, &c.State, &c.ChangedBy, &c.ChangedAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+
+	return changes, rows.Err()
+}
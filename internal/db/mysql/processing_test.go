@@ -0,0 +1,42 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mehmetalisavas/message-sender/internal/models"
+)
+
+func TestSetProcessingState(t *testing.T) {
+	ctx := context.Background()
+	store := testStorage()
+
+	change, err := store.SetProcessingState(ctx, models.ProcessingStateStopped, "tester")
+	if err != nil {
+		t.Fatalf("SetProcessingState() error = %v", err)
+	}
+
+	current, err := store.GetProcessingState(ctx)
+	if err != nil {
+		t.Fatalf("GetProcessingState() error = %v", err)
+	}
+	if current.ID != change.ID || current.State != models.ProcessingStateStopped || current.ChangedBy != "tester" {
+		t.Errorf("GetProcessingState() = %+v, want %+v", current, change)
+	}
+
+	_, err = store.SetProcessingState(ctx, models.ProcessingStateStarted, "tester")
+	if err != nil {
+		t.Fatalf("SetProcessingState() error = %v", err)
+	}
+
+	history, err := store.ListProcessingStateChanges(ctx, models.ListOptions{Limit: 2})
+	if err != nil {
+		t.Fatalf("ListProcessingStateChanges() error = %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("ListProcessingStateChanges() returned %d changes, expected 2", len(history))
+	}
+	if history[0].State != models.ProcessingStateStarted || history[1].State != models.ProcessingStateStopped {
+		t.Errorf("ListProcessingStateChanges() returned unexpected order: %+v", history)
+	}
+}
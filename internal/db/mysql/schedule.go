@@ -0,0 +1,259 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mehmetalisavas/message-sender/internal/models"
+	"github.com/mehmetalisavas/message-sender/pkg/smscontent"
+)
+
+const scheduleColumns = `id, name, cron_expression, timezone, recipient, content, template_id, template_version, locale, variables, enabled, next_run_at, last_run_at, created_at, updated_at`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSchedule(row rowScanner) (*models.Schedule, error) {
+	var (
+		s               models.Schedule
+		templateID      sql.NullInt64
+		templateVersion sql.NullInt64
+		locale          sql.NullString
+		variables       []byte
+		nextRunAt       sql.NullTime
+		lastRunAt       sql.NullTime
+	)
+	err := row.Scan(&s.ID, &s.Name, &s.CronExpression, &s.Timezone, &s.Recipient, &s.Content, &templateID, &templateVersion, &locale, &variables, &s.Enabled, &nextRunAt, &lastRunAt, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	s.TemplateVersion = int(templateVersion.Int64)
+	s.Locale = locale.String
+	if templateID.Valid {
+		id := int(templateID.Int64)
+		s.TemplateID = &id
+	}
+	if variables != nil {
+		if err := json.Unmarshal(variables, &s.Variables); err != nil {
+			return nil, err
+		}
+	}
+	if nextRunAt.Valid {
+		s.NextRunAt = &nextRunAt.Time
+	}
+	if lastRunAt.Valid {
+		s.LastRunAt = &lastRunAt.Time
+	}
+
+	return &s, nil
+}
+
+// CreateSchedule inserts a new recurring schedule.
+func (s *SqlStore) CreateSchedule(ctx context.Context, schedule models.Schedule) (*models.Schedule, error) {
+	query := `
+		INSERT INTO schedules (name, cron_expression, timezone, recipient, content, template_id, template_version, locale, variables, enabled, next_run_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	templateVersion, locale, variables, err := scheduleTemplateArgs(schedule)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.db.ExecContext(ctx, query, schedule.Name, schedule.CronExpression, schedule.Timezone, schedule.Recipient, schedule.Content, schedule.TemplateID, templateVersion, locale, variables, schedule.Enabled, schedule.NextRunAt)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetSchedule(ctx, int(id))
+}
+
+// scheduleTemplateArgs returns the template columns of the schedule, they are NULL for content schedules.
+func scheduleTemplateArgs(schedule models.Schedule) (sql.NullInt64, sql.NullString, []byte, error) {
+	if schedule.TemplateID == nil {
+		return sql.NullInt64{}, sql.NullString{}, nil, nil
+	}
+
+	variables, err := json.Marshal(schedule.Variables)
+	if err != nil {
+		return sql.NullInt64{}, sql.NullString{}, nil, err
+	}
+
+	return sql.NullInt64{Int64: int64(schedule.TemplateVersion), Valid: true}, sql.NullString{String: schedule.Locale, Valid: true}, variables, nil
+}
+
+// GetSchedule returns the schedule with the given ID.
+func (s *SqlStore) GetSchedule(ctx context.Context, id int) (*models.Schedule, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM schedules WHERE id = ?`
+
+	schedule, err := scanSchedule(s.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrNotFound
+	}
+
+	return schedule, err
+}
+
+// ListSchedules returns the schedules according to given options.
+func (s *SqlStore) ListSchedules(ctx context.Context, opts models.ListOptions) ([]models.Schedule, error) {
+	options := models.InitWithDefaultListOptions(opts)
+
+	query := `SELECT ` + scheduleColumns + ` FROM schedules ORDER BY id ASC LIMIT ? OFFSET ?`
+
+	rows, err := s.db.QueryContext(ctx, query, options.Limit, options.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := make([]models.Schedule, 0, options.Limit)
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, *schedule)
+	}
+
+	return schedules, rows.Err()
+}
+
+// UpdateSchedule updates the definition and the next run time of the given schedule.
+func (s *SqlStore) UpdateSchedule(ctx context.Context, schedule models.Schedule) (*models.Schedule, error) {
+	query := `
+		UPDATE schedules
+		SET name = ?, cron_expression = ?, timezone = ?, recipient = ?, content = ?,
+			template_id = ?, template_version = ?, locale = ?, variables = ?,
+			enabled = ?, next_run_at = ?, updated_at = NOW()
+		WHERE id = ?
+	`
+
+	templateVersion, locale, variables, err := scheduleTemplateArgs(schedule)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.db.ExecContext(ctx, query, schedule.Name, schedule.CronExpression, schedule.Timezone, schedule.Recipient, schedule.Content, schedule.TemplateID, templateVersion, locale, variables, schedule.Enabled, schedule.NextRunAt, schedule.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetSchedule(ctx, schedule.ID)
+}
+
+// DeleteSchedule deletes the schedule with the given ID. Already materialized messages are kept.
+func (s *SqlStore) DeleteSchedule(ctx context.Context, id int) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM schedules WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.ErrNotFound
+	}
+
+	return nil
+}
+
+// GetDueSchedules returns the enabled schedules whose next run time is not after now.
+func (s *SqlStore) GetDueSchedules(ctx context.Context, now time.Time, limit int) ([]models.Schedule, error) {
+	query := `
+		SELECT ` + scheduleColumns + `
+		FROM schedules
+		WHERE enabled = TRUE AND next_run_at <= ?
+		ORDER BY next_run_at ASC
+		LIMIT ?
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := make([]models.Schedule, 0, limit)
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, *schedule)
+	}
+
+	return schedules, rows.Err()
+}
+
+// MaterializeSchedule inserts the pending message of the schedule for its current fire time with
+// the given content, along with its creation event, and moves the schedule to nextRunAt. The unique (schedule_id, scheduled_for) key
+// and the conditional update make it safe to be called by several instances for the same fire time;
+// it reports whether this call created the message.
+func (s *SqlStore) MaterializeSchedule(ctx context.Context, schedule models.Schedule, content string, nextRunAt *time.Time) (bool, error) {
+	if schedule.NextRunAt == nil {
+		return false, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback() // Ensure rollback in case of any error
+
+	insertQuery := `
+		INSERT INTO messages (content, recipient, segments, status, schedule_id, scheduled_for)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	// Only the duplicate fire time is expected, any other error must not skip the run silently.
+	// A failed statement doesn't abort the transaction, so the schedule is still moved forward.
+	segments := smscontent.Analyze(content).Segments
+	result, err := tx.ExecContext(ctx, insertQuery, content, schedule.Recipient, segments, models.MessageStatusPending, schedule.ID, *schedule.NextRunAt)
+	inserted := err == nil
+	if err != nil && !isDuplicateEntry(err) {
+		return false, err
+	}
+
+	if inserted {
+		id, err := result.LastInsertId()
+		if err != nil {
+			return false, err
+		}
+		event := models.MessageEvent{
+			MessageID:  int(id),
+			FromStatus: models.MessageEventCreated,
+			ToStatus:   models.MessageStatusPending,
+			Actor:      models.MessageActorScheduler,
+			Reason:     fmt.Sprintf("schedule %d fired at %s", schedule.ID, schedule.NextRunAt.UTC().Format(time.RFC3339)),
+		}
+		if err := insertMessageEvents(ctx, tx, []models.MessageEvent{event}); err != nil {
+			return false, err
+		}
+	}
+
+	updateQuery := `
+		UPDATE schedules
+		SET next_run_at = ?, last_run_at = ?
+		WHERE id = ? AND next_run_at = ?
+	`
+	_, err = tx.ExecContext(ctx, updateQuery, nextRunAt, *schedule.NextRunAt, schedule.ID, *schedule.NextRunAt)
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return inserted, nil
+}
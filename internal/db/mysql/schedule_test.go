@@ -0,0 +1,74 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mehmetalisavas/message-sender/internal/models"
+)
+
+func TestMaterializeSchedule(t *testing.T) {
+	ctx := context.Background()
+	store := testStorage()
+
+	fireTime := time.Now().UTC().Truncate(time.Minute).Add(-time.Minute)
+	schedule, err := store.CreateSchedule(ctx, models.Schedule{
+		Name:           "test schedule",
+		CronExpression: "* * * * *",
+		Timezone:       "UTC",
+		Recipient:      "+905555555555",
+		Content:        "Scheduled message",
+		Enabled:        true,
+		NextRunAt:      &fireTime,
+	})
+	if err != nil {
+		t.Fatalf("CreateSchedule() error = %v", err)
+	}
+
+	due, err := store.GetDueSchedules(ctx, time.Now().UTC(), 100)
+	if err != nil {
+		t.Fatalf("GetDueSchedules() error = %v", err)
+	}
+	found := false
+	for _, s := range due {
+		found = found || s.ID == schedule.ID
+	}
+	if !found {
+		t.Fatalf("GetDueSchedules() did not return schedule %d", schedule.ID)
+	}
+
+	nextRunAt := fireTime.Add(time.Minute)
+
+	// Materializing the same fire time twice, as two instances would, creates a single message.
+	created, err := store.MaterializeSchedule(ctx, *schedule, schedule.Content, &nextRunAt)
+	if err != nil {
+		t.Fatalf("MaterializeSchedule() error = %v", err)
+	}
+	if !created {
+		t.Errorf("MaterializeSchedule() created = false, want true")
+	}
+
+	created, err = store.MaterializeSchedule(ctx, *schedule, schedule.Content, &nextRunAt)
+	if err != nil {
+		t.Fatalf("MaterializeSchedule() error = %v", err)
+	}
+	if created {
+		t.Errorf("MaterializeSchedule() created = true for an already materialized fire time")
+	}
+
+	updated, err := store.GetSchedule(ctx, schedule.ID)
+	if err != nil {
+		t.Fatalf("GetSchedule() error = %v", err)
+	}
+	if updated.NextRunAt == nil || !updated.NextRunAt.Equal(nextRunAt) {
+		t.Errorf("GetSchedule() next_run_at = %v, want %v", updated.NextRunAt, nextRunAt)
+	}
+
+	if err := store.DeleteSchedule(ctx, schedule.ID); err != nil {
+		t.Errorf("DeleteSchedule() error = %v", err)
+	}
+	if _, err := store.GetSchedule(ctx, schedule.ID); err != models.ErrNotFound {
+		t.Errorf("GetSchedule() error = %v, want %v", err, models.ErrNotFound)
+	}
+}
@@ -2,6 +2,16 @@ package mysql
 
 import (
 	"database/sql"
+	"errors"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+)
+
+const (
+	// errDuplicateEntry is the MySQL error number of unique key violations.
+	errDuplicateEntry = 1062
+	// errRowIsReferenced is the MySQL error number of deleting a row that a foreign key refers to.
+	errRowIsReferenced = 1451
 )
 
 // SqlStore represents a MySQL store.
@@ -15,3 +25,15 @@ func NewSqlStore(client *sql.DB) *SqlStore {
 		db: client,
 	}
 }
+
+// isDuplicateEntry reports whether err is caused by a unique key violation.
+func isDuplicateEntry(err error) bool {
+	var mysqlErr *mysqldriver.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == errDuplicateEntry
+}
+
+// isRowReferenced reports whether err is caused by deleting a row that is still referred to.
+func isRowReferenced(err error) bool {
+	var mysqlErr *mysqldriver.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == errRowIsReferenced
+}
@@ -0,0 +1,122 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/mehmetalisavas/message-sender/internal/models"
+)
+
+const suppressionColumns = `id, recipient, reason, source, created_at`
+
+func scanSuppression(row rowScanner) (*models.Suppression, error) {
+	var s models.Suppression
+	err := row.Scan(&s.ID, &s.Recipient, &s.Reason, &s.Source, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// AddSuppression adds the recipient to the suppression list. An existing entry
+// of the recipient is updated with the new reason and source, except that an entry
+// added through the API is left as it is by a keyword.
+func (s *SqlStore) AddSuppression(ctx context.Context, suppression models.Suppression) (*models.Suppression, error) {
+	query := `
+		INSERT INTO suppressions (recipient, reason, source)
+		VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			reason = IF(source = ? AND VALUES(source) = ?, reason, VALUES(reason)),
+			source = IF(source = ?, source, VALUES(source))
+	`
+
+	_, err := s.db.ExecContext(ctx, query, suppression.Recipient, suppression.Reason, suppression.Source,
+		models.SuppressionSourceAPI, models.SuppressionSourceKeyword, models.SuppressionSourceAPI)
+	if err != nil {
+		return nil, err
+	}
+
+	selectQuery := `SELECT ` + suppressionColumns + ` FROM suppressions WHERE recipient = ?`
+	return scanSuppression(s.db.QueryRowContext(ctx, selectQuery, suppression.Recipient))
+}
+
+// RemoveSuppression removes the recipient from the suppression list.
+func (s *SqlStore) RemoveSuppression(ctx context.Context, recipient string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM suppressions WHERE recipient = ?`, recipient)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.ErrNotFound
+	}
+
+	return nil
+}
+
+// RemoveSuppressionWithSource removes the recipient from the suppression list if it was added from the given source.
+func (s *SqlStore) RemoveSuppressionWithSource(ctx context.Context, recipient string, source models.SuppressionSource) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM suppressions WHERE recipient = ? AND source = ?`, recipient, source)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.ErrNotFound
+	}
+
+	return nil
+}
+
+// ListSuppressions returns the suppression list, newest first.
+func (s *SqlStore) ListSuppressions(ctx context.Context, opts models.ListOptions) ([]models.Suppression, error) {
+	options := models.InitWithDefaultListOptions(opts)
+
+	query := `
+		SELECT ` + suppressionColumns + `
+		FROM suppressions
+		ORDER BY id DESC
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, options.Limit, options.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suppressions := make([]models.Suppression, 0, options.Limit)
+	for rows.Next() {
+		suppression, err := scanSuppression(rows)
+		if err != nil {
+			return nil, err
+		}
+		suppressions = append(suppressions, *suppression)
+	}
+
+	return suppressions, rows.Err()
+}
+
+// IsSuppressed reports whether the recipient is on the suppression list.
+func (s *SqlStore) IsSuppressed(ctx context.Context, recipient string) (bool, error) {
+	var id int
+	err := s.db.QueryRowContext(ctx, `SELECT id FROM suppressions WHERE recipient = ?`, recipient).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
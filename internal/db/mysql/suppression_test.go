@@ -0,0 +1,65 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mehmetalisavas/message-sender/internal/models"
+)
+
+func TestSuppressions(t *testing.T) {
+	ctx := context.Background()
+	store := testStorage()
+
+	recipient := "+905555555599"
+	store.RemoveSuppression(ctx, recipient)
+
+	suppressed, err := store.IsSuppressed(ctx, recipient)
+	if err != nil {
+		t.Fatalf("IsSuppressed() error = %v", err)
+	}
+	if suppressed {
+		t.Fatalf("IsSuppressed() = true before the recipient is added")
+	}
+
+	added, err := store.AddSuppression(ctx, models.Suppression{Recipient: recipient, Reason: "requested", Source: models.SuppressionSourceAPI})
+	if err != nil {
+		t.Fatalf("AddSuppression() error = %v", err)
+	}
+
+	// Adding the recipient again updates the existing entry, but a keyword doesn't replace an API entry.
+	updated, err := store.AddSuppression(ctx, models.Suppression{Recipient: recipient, Reason: "STOP", Source: models.SuppressionSourceKeyword})
+	if err != nil {
+		t.Fatalf("AddSuppression() error = %v", err)
+	}
+	if updated.ID != added.ID || updated.Source != models.SuppressionSourceAPI || updated.Reason != "requested" {
+		t.Errorf("AddSuppression() = %+v, want unchanged entry %d", updated, added.ID)
+	}
+	if err := store.RemoveSuppressionWithSource(ctx, recipient, models.SuppressionSourceKeyword); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("RemoveSuppressionWithSource() error = %v, want %v", err, models.ErrNotFound)
+	}
+
+	updated, err = store.AddSuppression(ctx, models.Suppression{Recipient: recipient, Reason: "requested again", Source: models.SuppressionSourceAPI})
+	if err != nil {
+		t.Fatalf("AddSuppression() error = %v", err)
+	}
+	if updated.ID != added.ID || updated.Reason != "requested again" {
+		t.Errorf("AddSuppression() = %+v, want updated entry %d", updated, added.ID)
+	}
+
+	suppressed, err = store.IsSuppressed(ctx, recipient)
+	if err != nil {
+		t.Fatalf("IsSuppressed() error = %v", err)
+	}
+	if !suppressed {
+		t.Errorf("IsSuppressed() = false after the recipient is added")
+	}
+
+	if err := store.RemoveSuppression(ctx, recipient); err != nil {
+		t.Fatalf("RemoveSuppression() error = %v", err)
+	}
+	if err := store.RemoveSuppression(ctx, recipient); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("RemoveSuppression() error = %v, want %v", err, models.ErrNotFound)
+	}
+}
@@ -0,0 +1,196 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/mehmetalisavas/message-sender/internal/models"
+)
+
+const templateColumns = `id, name, default_locale, latest_version, created_at, updated_at`
+
+func scanTemplate(row rowScanner) (*models.Template, error) {
+	var t models.Template
+	err := row.Scan(&t.ID, &t.Name, &t.DefaultLocale, &t.Version, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// CreateTemplate inserts a new template with its bodies as version 1.
+func (s *SqlStore) CreateTemplate(ctx context.Context, template models.Template) (*models.Template, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() // Ensure rollback in case of any error
+
+	query := `
+		INSERT INTO templates (name, default_locale, latest_version)
+		VALUES (?, ?, 1)
+	`
+	result, err := tx.ExecContext(ctx, query, template.Name, template.DefaultLocale)
+	if isDuplicateEntry(err) {
+		return nil, models.ErrAlreadyExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := insertTemplateBodies(ctx, tx, int(id), 1, template.Bodies); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetTemplate(ctx, int(id), 0)
+}
+
+// GetTemplate returns the template with the given ID and the bodies of the given version.
+// The latest version is returned when version is zero.
+func (s *SqlStore) GetTemplate(ctx context.Context, id, version int) (*models.Template, error) {
+	query := `SELECT ` + templateColumns + ` FROM templates WHERE id = ?`
+
+	template, err := scanTemplate(s.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if version != 0 {
+		template.Version = version
+	}
+
+	bodiesQuery := `
+		SELECT locale, body
+		FROM template_bodies
+		WHERE template_id = ? AND version = ?
+	`
+	rows, err := s.db.QueryContext(ctx, bodiesQuery, id, template.Version)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	template.Bodies = make(map[string]string)
+	for rows.Next() {
+		var locale, body string
+		if err := rows.Scan(&locale, &body); err != nil {
+			return nil, err
+		}
+		template.Bodies[locale] = body
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(template.Bodies) == 0 {
+		return nil, models.ErrNotFound
+	}
+
+	return template, nil
+}
+
+// ListTemplates returns the templates according to given options, without their bodies.
+func (s *SqlStore) ListTemplates(ctx context.Context, opts models.ListOptions) ([]models.Template, error) {
+	options := models.InitWithDefaultListOptions(opts)
+
+	query := `SELECT ` + templateColumns + ` FROM templates ORDER BY id ASC LIMIT ? OFFSET ?`
+
+	rows, err := s.db.QueryContext(ctx, query, options.Limit, options.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := make([]models.Template, 0, options.Limit)
+	for rows.Next() {
+		template, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *template)
+	}
+
+	return templates, rows.Err()
+}
+
+// AddTemplateVersion stores the bodies as the next version of the template with the given ID.
+func (s *SqlStore) AddTemplateVersion(ctx context.Context, id int, bodies map[string]string) (*models.Template, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() // Ensure rollback in case of any error
+
+	// Lock the template so that concurrent updates get consecutive versions.
+	var version int
+	err = tx.QueryRowContext(ctx, `SELECT latest_version FROM templates WHERE id = ? FOR UPDATE`, id).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	version++
+
+	if err := insertTemplateBodies(ctx, tx, id, version, bodies); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE templates SET latest_version = ?, updated_at = NOW() WHERE id = ?`, version, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetTemplate(ctx, id, version)
+}
+
+// DeleteTemplate deletes the template with the given ID and all of its versions.
+// It returns models.ErrInUse while campaigns or schedules refer to the template.
+func (s *SqlStore) DeleteTemplate(ctx context.Context, id int) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM templates WHERE id = ?`, id)
+	if isRowReferenced(err) {
+		return models.ErrInUse
+	}
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.ErrNotFound
+	}
+
+	return nil
+}
+
+func insertTemplateBodies(ctx context.Context, tx *sql.Tx, id, version int, bodies map[string]string) error {
+	query := `
+		INSERT INTO template_bodies (template_id, version, locale, body)
+		VALUES (?, ?, ?, ?)
+	`
+	for locale, body := range bodies {
+		if _, err := tx.ExecContext(ctx, query, id, version, locale, body); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
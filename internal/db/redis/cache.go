@@ -2,16 +2,29 @@ package redis
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/mehmetalisavas/message-sender/config"
+	"github.com/mehmetalisavas/message-sender/internal/models"
 )
 
 var ErrEmptyMessageID = errors.New("message ID cannot be empty")
 
+// releaseDedupeKeyScript deletes the dedupe key only while it is still held by the given message.
+var releaseDedupeKeyScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
 type RedisCacheStore struct {
 	client *redis.Client
 }
@@ -54,3 +67,72 @@ func (r *RedisCacheStore) GetMessageValue(ctx context.Context, messageId string)
 
 	return time.Parse(time.RFC3339, value)
 }
+
+// ReserveDedupeKey records the message as the first one sent to the recipient with the content
+// for the given window. It returns 0 when the message holds the reservation, or the ID of the
+// message that reserved it earlier, in which case the message is a duplicate.
+func (r *RedisCacheStore) ReserveDedupeKey(ctx context.Context, recipient, content string, messageID int, window time.Duration) (int, error) {
+	key := dedupeKey(recipient, content)
+
+	reserved, err := r.client.SetNX(ctx, key, messageID, window).Result()
+	if err != nil || reserved {
+		return 0, err
+	}
+
+	originalID, err := r.client.Get(ctx, key).Int()
+	if errors.Is(err, redis.Nil) {
+		// The reservation expired in the meantime.
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// A message that is processed again, e.g. after being released to pending, is not a duplicate of itself.
+	if originalID == messageID {
+		return 0, nil
+	}
+	return originalID, nil
+}
+
+// ReleaseDedupeKey releases the reservation of the recipient and content if the message still holds it,
+// so that a message which wasn't delivered doesn't mark a later one as a duplicate.
+func (r *RedisCacheStore) ReleaseDedupeKey(ctx context.Context, recipient, content string, messageID int) error {
+	return releaseDedupeKeyScript.Run(ctx, r.client, []string{dedupeKey(recipient, content)}, strconv.Itoa(messageID)).Err()
+}
+
+func dedupeKey(recipient, content string) string {
+	sum := sha256.Sum256([]byte(recipient + "\x00" + content))
+	return fmt.Sprintf("dedupe:%s", hex.EncodeToString(sum[:]))
+}
+
+// GetDeliveryMarker returns the delivery marker of the message with the given ID, nil if there is none.
+func (r *RedisCacheStore) GetDeliveryMarker(ctx context.Context, messageID int) (*models.DeliveryMarker, error) {
+	value, err := r.client.Get(ctx, deliveryKey(messageID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var marker models.DeliveryMarker
+	if err := json.Unmarshal(value, &marker); err != nil {
+		return nil, err
+	}
+	return &marker, nil
+}
+
+// SetDeliveryMarker records the delivery marker of the message with the given ID for ttl.
+func (r *RedisCacheStore) SetDeliveryMarker(ctx context.Context, messageID int, marker models.DeliveryMarker, ttl time.Duration) error {
+	value, err := json.Marshal(marker)
+	if err != nil {
+		return err
+	}
+
+	return r.client.Set(ctx, deliveryKey(messageID), value, ttl).Err()
+}
+
+func deliveryKey(messageID int) string {
+	return fmt.Sprintf("delivery:%d", messageID)
+}
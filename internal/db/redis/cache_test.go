@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/mehmetalisavas/message-sender/config"
+	"github.com/mehmetalisavas/message-sender/internal/models"
 	"github.com/sethvargo/go-envconfig"
 )
 
@@ -153,3 +154,87 @@ func TestRedisCacheStore_GetMessageValue_InvalidFormat(t *testing.T) {
 		t.Errorf("GetMessageValue() error = %v, wantErr %v", err, true)
 	}
 }
+
+func TestRedisCacheStore_ReserveDedupeKey(t *testing.T) {
+	store, cleanup, err := setupTestRedis()
+	if err != nil {
+		t.Fatalf("failed to set up test Redis: %v", err)
+	}
+	defer cleanup()
+
+	ctx := context.Background()
+	window := time.Minute
+
+	originalID, err := store.ReserveDedupeKey(ctx, "+905555555555", "hello", 1, window)
+	if err != nil || originalID != 0 {
+		t.Fatalf("ReserveDedupeKey() = %d, %v, want 0, nil", originalID, err)
+	}
+
+	// The same message processed again is not a duplicate of itself.
+	originalID, err = store.ReserveDedupeKey(ctx, "+905555555555", "hello", 1, window)
+	if err != nil || originalID != 0 {
+		t.Errorf("ReserveDedupeKey() = %d, %v, want 0, nil", originalID, err)
+	}
+
+	originalID, err = store.ReserveDedupeKey(ctx, "+905555555555", "hello", 2, window)
+	if err != nil || originalID != 1 {
+		t.Errorf("ReserveDedupeKey() = %d, %v, want 1, nil", originalID, err)
+	}
+
+	originalID, err = store.ReserveDedupeKey(ctx, "+905555555555", "hello again", 3, window)
+	if err != nil || originalID != 0 {
+		t.Errorf("ReserveDedupeKey() = %d, %v, want 0, nil", originalID, err)
+	}
+
+	// Only the message holding the reservation releases it.
+	if err := store.ReleaseDedupeKey(ctx, "+905555555555", "hello", 2); err != nil {
+		t.Fatalf("ReleaseDedupeKey() error = %v", err)
+	}
+	originalID, err = store.ReserveDedupeKey(ctx, "+905555555555", "hello", 2, window)
+	if err != nil || originalID != 1 {
+		t.Errorf("ReserveDedupeKey() = %d, %v, want 1, nil", originalID, err)
+	}
+
+	if err := store.ReleaseDedupeKey(ctx, "+905555555555", "hello", 1); err != nil {
+		t.Fatalf("ReleaseDedupeKey() error = %v", err)
+	}
+	originalID, err = store.ReserveDedupeKey(ctx, "+905555555555", "hello", 2, window)
+	if err != nil || originalID != 0 {
+		t.Errorf("ReserveDedupeKey() = %d, %v, want 0, nil", originalID, err)
+	}
+}
+
+func TestRedisCacheStore_DeliveryMarker(t *testing.T) {
+	store, cleanup, err := setupTestRedis()
+	if err != nil {
+		t.Fatalf("failed to set up test Redis: %v", err)
+	}
+	defer cleanup()
+
+	ctx := context.Background()
+
+	marker, err := store.GetDeliveryMarker(ctx, 1)
+	if err != nil || marker != nil {
+		t.Fatalf("GetDeliveryMarker() = %v, %v, want nil, nil", marker, err)
+	}
+
+	sentAt := time.Now().UTC().Truncate(time.Second)
+	want := models.DeliveryMarker{
+		State:             models.DeliveryStateSent,
+		ProviderMessageID: "provider-id",
+		Provider:          "webhook",
+		SentAt:            sentAt,
+	}
+	if err := store.SetDeliveryMarker(ctx, 1, want, time.Minute); err != nil {
+		t.Fatalf("SetDeliveryMarker() error = %v", err)
+	}
+
+	marker, err = store.GetDeliveryMarker(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetDeliveryMarker() error = %v", err)
+	}
+	if marker == nil || marker.State != want.State || marker.ProviderMessageID != want.ProviderMessageID ||
+		marker.Provider != want.Provider || !marker.SentAt.Equal(sentAt) {
+		t.Errorf("GetDeliveryMarker() = %+v, want %+v", marker, want)
+	}
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrCampaignCancelled is returned when recipients are added to a cancelled campaign.
+	ErrCampaignCancelled = errors.New("campaign is cancelled")
+	// ErrInvalidCampaignTransition is returned when a campaign can't be moved to the requested status.
+	ErrInvalidCampaignTransition = errors.New("invalid campaign status transition")
+)
+
+// CampaignStatus represents the lifecycle of a campaign.
+type CampaignStatus string
+
+const (
+	// CampaignStatusDraft campaigns collect recipients, their messages are not sent yet.
+	CampaignStatusDraft CampaignStatus = "draft"
+	// CampaignStatusRunning campaigns have their pending messages sent.
+	CampaignStatusRunning CampaignStatus = "running"
+	// CampaignStatusPaused campaigns keep their pending messages until started again.
+	CampaignStatusPaused CampaignStatus = "paused"
+	// CampaignStatusCancelled campaigns had their pending messages cancelled, it is final.
+	CampaignStatusCancelled CampaignStatus = "cancelled"
+)
+
+// CanTransitionTo reports whether a campaign in status s can be moved to next.
+func (s CampaignStatus) CanTransitionTo(next CampaignStatus) bool {
+	switch next {
+	case CampaignStatusRunning:
+		return s == CampaignStatusDraft || s == CampaignStatusPaused
+	case CampaignStatusPaused:
+		return s == CampaignStatusRunning
+	case CampaignStatusCancelled:
+		return s != CampaignStatusCancelled
+	}
+	return false
+}
+
+// Campaign groups the messages of a bulk send so that it can be controlled and tracked as a whole.
+type Campaign struct {
+	ID      int            `json:"id"`
+	Name    string         `json:"name"`
+	Channel MessageChannel `json:"channel"`
+	// Content is sent to every recipient unless the campaign uses a template.
+	Content    string `json:"content,omitempty"`
+	TemplateID *int   `json:"template_id,omitempty"`
+	// TemplateVersion is pinned when the campaign is created, so that every recipient gets the same wording.
+	TemplateVersion int            `json:"template_version,omitempty"`
+	Locale          string         `json:"locale,omitempty"`
+	Status          CampaignStatus `json:"status"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
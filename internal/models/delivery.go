@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// DeliveryState is the progress of handing a message over to the provider.
+type DeliveryState string
+
+const (
+	// DeliveryStateInFlight is recorded right before the provider is called.
+	DeliveryStateInFlight DeliveryState = "in-flight"
+	// DeliveryStateSent is recorded as soon as the provider accepted the message.
+	DeliveryStateSent DeliveryState = "sent"
+)
+
+// DeliveryMarker records the delivery of a message outside of the database, so that a message
+// whose status couldn't be updated after it was sent is reconciled instead of being sent again.
+type DeliveryMarker struct {
+	State DeliveryState `json:"state"`
+	// ProviderMessageID, Provider and SentAt are set once the message is sent.
+	ProviderMessageID string    `json:"provider_message_id,omitempty"`
+	Provider          string    `json:"provider,omitempty"`
+	SentAt            time.Time `json:"sent_at,omitempty"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "errors"
+
+// ErrNotFound is returned when the requested entity does not exist in the storage.
+var ErrNotFound = errors.New("not found")
+
+// ErrAlreadyExists is returned when an entity conflicts with an existing one, e.g. by name.
+var ErrAlreadyExists = errors.New("already exists")
+
+// ErrInUse is returned when an entity can't be deleted because other entities refer to it.
+var ErrInUse = errors.New("in use")
+
+// ErrLeaseLost is returned when a message is no longer leased with the given token,
+// because its lease expired and another worker reclaimed it.
+var ErrLeaseLost = errors.New("message lease lost")
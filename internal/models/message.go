@@ -1,6 +1,13 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MaxContentLength is the maximum number of characters of a message content, enforced by the schema as well.
+const MaxContentLength = 255
 
 type MessageStatus string
 
@@ -9,14 +16,94 @@ const (
 	MessageStatusProcessing MessageStatus = "processing"
 	MessageStatusSent       MessageStatus = "sent"
 	MessageStatusFailed     MessageStatus = "failed"
+	// MessageStatusExpired marks messages that couldn't be delivered within the delivery timeout.
+	MessageStatusExpired MessageStatus = "expired"
+	// MessageStatusSuppressed marks messages that weren't sent because the recipient opted out.
+	MessageStatusSuppressed MessageStatus = "suppressed"
+	// MessageStatusCancelled marks pending messages cancelled on their own or with their campaign.
+	MessageStatusCancelled MessageStatus = "cancelled"
+	// MessageStatusDeduplicated marks messages with the same recipient and content as a recently sent one.
+	MessageStatusDeduplicated MessageStatus = "deduplicated"
 )
 
+// ErrInvalidMessageTransition is returned when a message can't be moved to the requested status.
+var ErrInvalidMessageTransition = errors.New("invalid message status transition")
+
+// StatusConflictError is returned when the status of a message is updated from a status it
+// is no longer in, e.g. because another worker already completed the message.
+type StatusConflictError struct {
+	ID       int
+	Expected MessageStatus
+	Actual   MessageStatus
+}
+
+func (e *StatusConflictError) Error() string {
+	return fmt.Sprintf("message %d is %s, expected %s", e.ID, e.Actual, e.Expected)
+}
+
+// CanTransitionTo reports whether a message in status s can be moved to next.
+// Sent, failed, expired, suppressed, cancelled and deduplicated are final.
+func (s MessageStatus) CanTransitionTo(next MessageStatus) bool {
+	switch s {
+	case MessageStatusPending:
+		return next == MessageStatusProcessing || next == MessageStatusCancelled
+	case MessageStatusProcessing:
+		switch next {
+		// Processing messages are processed again when their lease expires, or released back to pending.
+		case MessageStatusProcessing, MessageStatusPending,
+			MessageStatusSent, MessageStatusFailed, MessageStatusExpired, MessageStatusSuppressed, MessageStatusDeduplicated:
+			return true
+		}
+	}
+	return false
+}
+
+// MessageChannel represents the channel a message is delivered through.
+type MessageChannel string
+
+const (
+	MessageChannelSMS   MessageChannel = "sms"
+	MessageChannelEmail MessageChannel = "email"
+	MessageChannelPush  MessageChannel = "push"
+	MessageChannelChat  MessageChannel = "chat"
+)
+
+// Valid reports whether c is a known channel.
+func (c MessageChannel) Valid() bool {
+	switch c {
+	case MessageChannelSMS, MessageChannelEmail, MessageChannelPush, MessageChannelChat:
+		return true
+	}
+	return false
+}
+
 // Message represents a message entity.
 type Message struct {
-	ID        int           `json:"id"`
-	Recipient string        `json:"recipient"`
-	Content   string        `json:"content"`
-	Status    MessageStatus `json:"status"`
-	CreatedAt time.Time     `json:"created_at"`
-	UpdatedAt time.Time     `json:"updated_at"`
+	ID        int            `json:"id"`
+	Recipient string         `json:"recipient"`
+	Content   string         `json:"content"`
+	Channel   MessageChannel `json:"channel"`
+	// Provider is the name of the notification provider that handled the message, if routed.
+	Provider string `json:"provider,omitempty"`
+	// Segments is the number of SMS segments the content is sent in, zero for other channels.
+	Segments int `json:"segments,omitempty"`
+	// CampaignID is the campaign the message belongs to, zero if none.
+	CampaignID int           `json:"campaign_id,omitempty"`
+	Status     MessageStatus `json:"status"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+	// LeaseToken identifies the claim of a processing message, only its holder can complete the message.
+	LeaseToken string `json:"-"`
+}
+
+// MessageStatusUpdate completes a processing message leased with LeaseToken.
+type MessageStatusUpdate struct {
+	ID         int
+	LeaseToken string
+	Status     MessageStatus
+	// Provider is recorded along with the status unless it is empty.
+	Provider string
+	// Actor and Reason are recorded in the message events.
+	Actor  string
+	Reason string
 }
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Actors of the status transitions made while processing messages. Transitions made
+// through the API are recorded with the identity of the caller instead.
+const (
+	MessageActorProducer  = "producer"
+	MessageActorConsumer  = "consumer"
+	MessageActorScheduler = "scheduler"
+)
+
+// MessageEventCreated is the status the first event of a message moves from, recorded in the
+// same transaction that creates the message so that its timeline starts with its origin.
+const MessageEventCreated MessageStatus = "created"
+
+// MessageEvent records a status transition of a message, who made it and why.
+type MessageEvent struct {
+	ID         int           `json:"id"`
+	MessageID  int           `json:"message_id"`
+	FromStatus MessageStatus `json:"from_status"`
+	ToStatus   MessageStatus `json:"to_status"`
+	Actor      string        `json:"actor"`
+	Reason     string        `json:"reason,omitempty"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
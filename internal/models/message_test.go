@@ -0,0 +1,30 @@
+package models
+
+import "testing"
+
+func TestMessageStatus_CanTransitionTo(t *testing.T) {
+	statuses := []MessageStatus{
+		MessageStatusPending, MessageStatusProcessing, MessageStatusSent, MessageStatusFailed,
+		MessageStatusExpired, MessageStatusSuppressed, MessageStatusCancelled, MessageStatusDeduplicated,
+	}
+	allowed := map[MessageStatus][]MessageStatus{
+		MessageStatusPending: {MessageStatusProcessing, MessageStatusCancelled},
+		MessageStatusProcessing: {
+			MessageStatusPending, MessageStatusProcessing, MessageStatusSent, MessageStatusFailed,
+			MessageStatusExpired, MessageStatusSuppressed, MessageStatusDeduplicated,
+		},
+		// Every other status is final.
+	}
+
+	for _, from := range statuses {
+		want := make(map[MessageStatus]bool)
+		for _, to := range allowed[from] {
+			want[to] = true
+		}
+		for _, to := range statuses {
+			if got := from.CanTransitionTo(to); got != want[to] {
+				t.Errorf("%s.CanTransitionTo(%s) = %v, want %v", from, to, got, want[to])
+			}
+		}
+	}
+}
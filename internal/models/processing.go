@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+type ProcessingState string
+
+const (
+	ProcessingStateStarted ProcessingState = "started"
+	ProcessingStateStopped ProcessingState = "stopped"
+	// ProcessingStatePaused stops fetching new messages after the current batch is dispatched.
+	ProcessingStatePaused ProcessingState = "paused"
+	// ProcessingStateRunOnce dispatches a single batch and then stops. It is never persisted.
+	ProcessingStateRunOnce ProcessingState = "run-once"
+)
+
+// ProcessingCommand represents an admin command that changes the processing state.
+type ProcessingCommand string
+
+const (
+	ProcessingCommandStart             ProcessingCommand = "start"
+	ProcessingCommandStop              ProcessingCommand = "stop"
+	ProcessingCommandPauseAfterCurrent ProcessingCommand = "pause-after-current"
+	ProcessingCommandRunOnce           ProcessingCommand = "run-once"
+)
+
+// ProcessingStateChange represents a change of the cluster-wide message processing state.
+type ProcessingStateChange struct {
+	ID        int             `json:"id"`
+	State     ProcessingState `json:"state"`
+	ChangedBy string          `json:"changed_by"`
+	ChangedAt time.Time       `json:"changed_at"`
+}
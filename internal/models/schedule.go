@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Schedule represents a recurring message that is materialized as a pending message at every fire time.
+type Schedule struct {
+	ID             int    `json:"id"`
+	Name           string `json:"name"`
+	CronExpression string `json:"cron_expression"`
+	// Timezone is the IANA time zone the cron expression is evaluated in.
+	Timezone  string `json:"timezone"`
+	Recipient string `json:"recipient"`
+	// Content is sent at every fire time unless the schedule uses a template.
+	Content    string `json:"content"`
+	TemplateID *int   `json:"template_id,omitempty"`
+	// TemplateVersion is pinned when the schedule is saved, so that every fire time gets the same wording.
+	TemplateVersion int                    `json:"template_version,omitempty"`
+	Locale          string                 `json:"locale,omitempty"`
+	Variables       map[string]interface{} `json:"variables,omitempty"`
+	Enabled         bool                   `json:"enabled"`
+	// NextRunAt is nil when the schedule is disabled or never fires again.
+	NextRunAt *time.Time `json:"next_run_at"`
+	LastRunAt *time.Time `json:"last_run_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
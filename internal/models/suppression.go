@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// SuppressionSource tells how a recipient ended up on the suppression list.
+type SuppressionSource string
+
+const (
+	// SuppressionSourceAPI is used for entries added through the API.
+	SuppressionSourceAPI SuppressionSource = "api"
+	// SuppressionSourceKeyword is used for recipients that replied with an opt-out keyword like STOP.
+	SuppressionSourceKeyword SuppressionSource = "keyword"
+)
+
+// Suppression represents a recipient that opted out, no message is sent to it.
+type Suppression struct {
+	ID        int               `json:"id"`
+	Recipient string            `json:"recipient"`
+	Reason    string            `json:"reason"`
+	Source    SuppressionSource `json:"source"`
+	CreatedAt time.Time         `json:"created_at"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Template represents a named message wording. Every change of the bodies
+// creates a new version, so that messages can refer to the exact wording.
+type Template struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// DefaultLocale is used when a message asks for a locale the template has no body for.
+	DefaultLocale string `json:"default_locale"`
+	// Version is the version of Bodies, the latest one unless asked otherwise.
+	Version int `json:"version"`
+	// Bodies are text/template sources keyed by locale, e.g. "en" or "tr-TR".
+	Bodies    map[string]string `json:"bodies,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// Body returns the body of the given locale. It falls back to the language of
+// the locale ("tr" for "tr-TR") and then to the default locale.
+func (t Template) Body(locale string) (string, bool) {
+	if body, ok := t.Bodies[locale]; ok {
+		return body, true
+	}
+	if language, _, found := strings.Cut(locale, "-"); found {
+		if body, ok := t.Bodies[language]; ok {
+			return body, true
+		}
+	}
+
+	body, ok := t.Bodies[t.DefaultLocale]
+	return body, ok
+}
@@ -0,0 +1,108 @@
+package processing
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/mehmetalisavas/message-sender/internal/models"
+)
+
+// Controller holds the local message processing state. It is safe for concurrent use
+// and notifies the listeners on every state change, so they don't have to poll it.
+// It also carries the run-now triggers that ask the producer to fetch immediately.
+type Controller struct {
+	state atomic.Value // models.ProcessingState
+
+	mu      sync.Mutex
+	changed chan struct{}
+
+	trigger chan struct{}
+	// triggerBatchSize is the largest batch size requested by the pending trigger, 0 means default.
+	triggerBatchSize int
+}
+
+// NewController creates a new Controller instance with the given initial state.
+func NewController(state models.ProcessingState) *Controller {
+	c := &Controller{
+		changed: make(chan struct{}),
+		trigger: make(chan struct{}, 1),
+	}
+	c.state.Store(state)
+
+	return c
+}
+
+// State returns the current processing state.
+func (c *Controller) State() models.ProcessingState {
+	return c.state.Load().(models.ProcessingState)
+}
+
+// SetState sets the processing state and notifies the listeners.
+func (c *Controller) SetState(state models.ProcessingState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.state.Store(state)
+	c.notify()
+}
+
+// CompareAndSetState sets the processing state only if the current state is old.
+// It reports whether the state has been changed.
+func (c *Controller) CompareAndSetState(old, new models.ProcessingState) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.state.CompareAndSwap(old, new) {
+		return false
+	}
+	c.notify()
+
+	return true
+}
+
+// Changed returns a channel that is closed on the next state change.
+// Listeners should call it again after every notification.
+func (c *Controller) Changed() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.changed
+}
+
+// TriggerRun asks the producer to fetch and dispatch pending messages immediately.
+// A batchSize of 0 uses the producer's default. Concurrent triggers that are not
+// consumed yet are coalesced into a single run with the largest requested batch size.
+func (c *Controller) TriggerRun(batchSize int) {
+	c.mu.Lock()
+	if batchSize > c.triggerBatchSize {
+		c.triggerBatchSize = batchSize
+	}
+	c.mu.Unlock()
+
+	select {
+	case c.trigger <- struct{}{}:
+	default: // a run is already pending
+	}
+}
+
+// Triggered returns the channel that receives a value when a run is triggered.
+func (c *Controller) Triggered() <-chan struct{} {
+	return c.trigger
+}
+
+// TakeTriggerBatchSize returns the batch size of the pending trigger and resets it.
+func (c *Controller) TakeTriggerBatchSize() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	batchSize := c.triggerBatchSize
+	c.triggerBatchSize = 0
+
+	return batchSize
+}
+
+// notify wakes up the listeners. c.mu must be held.
+func (c *Controller) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
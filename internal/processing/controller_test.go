@@ -0,0 +1,77 @@
+package processing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mehmetalisavas/message-sender/internal/models"
+)
+
+func TestController_SetStateNotifies(t *testing.T) {
+	c := NewController(models.ProcessingStateStarted)
+	changed := c.Changed()
+
+	c.SetState(models.ProcessingStateStopped)
+
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatalf("expected change notification, got none")
+	}
+
+	if c.State() != models.ProcessingStateStopped {
+		t.Errorf("expected state %s, got %s", models.ProcessingStateStopped, c.State())
+	}
+
+	select {
+	case <-c.Changed():
+		t.Errorf("expected a fresh notification channel after a change")
+	default:
+	}
+}
+
+func TestController_CompareAndSetState(t *testing.T) {
+	c := NewController(models.ProcessingStateRunOnce)
+
+	if c.CompareAndSetState(models.ProcessingStateStarted, models.ProcessingStateStopped) {
+		t.Errorf("expected compare and set to fail for a different current state")
+	}
+	if c.State() != models.ProcessingStateRunOnce {
+		t.Errorf("expected state %s, got %s", models.ProcessingStateRunOnce, c.State())
+	}
+
+	if !c.CompareAndSetState(models.ProcessingStateRunOnce, models.ProcessingStateStopped) {
+		t.Errorf("expected compare and set to succeed")
+	}
+	if c.State() != models.ProcessingStateStopped {
+		t.Errorf("expected state %s, got %s", models.ProcessingStateStopped, c.State())
+	}
+}
+
+func TestController_TriggerRunCoalesces(t *testing.T) {
+	c := NewController(models.ProcessingStateStarted)
+
+	c.TriggerRun(10)
+	c.TriggerRun(50)
+	c.TriggerRun(0)
+
+	select {
+	case <-c.Triggered():
+	default:
+		t.Fatalf("expected a pending trigger")
+	}
+
+	if batchSize := c.TakeTriggerBatchSize(); batchSize != 50 {
+		t.Errorf("expected batch size 50, got %d", batchSize)
+	}
+
+	select {
+	case <-c.Triggered():
+		t.Errorf("expected concurrent triggers to be coalesced into one")
+	default:
+	}
+
+	if batchSize := c.TakeTriggerBatchSize(); batchSize != 0 {
+		t.Errorf("expected batch size to be reset, got %d", batchSize)
+	}
+}
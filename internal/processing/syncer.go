@@ -0,0 +1,115 @@
+package processing
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mehmetalisavas/message-sender/internal/models"
+	"github.com/mehmetalisavas/message-sender/internal/service"
+)
+
+var ErrInvalidCommand = errors.New("invalid command")
+
+// Syncer keeps the local processing controller in sync with the cluster-wide
+// processing state persisted in the storage, so that every instance observes
+// the commands regardless of which instance received them.
+type Syncer struct {
+	controller     *Controller
+	storageService service.Storage
+	interval       time.Duration
+
+	mu           sync.Mutex
+	lastChangeID int
+}
+
+// NewSyncer creates a new Syncer instance that polls the storage in the given interval.
+func NewSyncer(controller *Controller, storageService service.Storage, interval time.Duration) *Syncer {
+	return &Syncer{
+		controller:     controller,
+		storageService: storageService,
+		interval:       interval,
+	}
+}
+
+// Load reads the persisted processing state and applies it to the controller.
+// When no state has been persisted yet, the controller is left untouched.
+func (s *Syncer) Load(ctx context.Context) error {
+	change, err := s.storageService.GetProcessingState(ctx)
+	if errors.Is(err, models.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	s.apply(change)
+	return nil
+}
+
+// Execute persists the state the given command leads to on behalf of changedBy and applies it locally.
+//
+// run-once is persisted as stopped, so the other instances stop, while this instance
+// dispatches a single batch before stopping as well. The batch itself is not persisted,
+// it is lost if this instance stops before dispatching it.
+func (s *Syncer) Execute(ctx context.Context, command models.ProcessingCommand, changedBy string) (*models.ProcessingStateChange, error) {
+	var state models.ProcessingState
+	switch command {
+	case models.ProcessingCommandStart:
+		state = models.ProcessingStateStarted
+	case models.ProcessingCommandStop, models.ProcessingCommandRunOnce:
+		state = models.ProcessingStateStopped
+	case models.ProcessingCommandPauseAfterCurrent:
+		state = models.ProcessingStatePaused
+	default:
+		return nil, ErrInvalidCommand
+	}
+
+	change, err := s.storageService.SetProcessingState(ctx, state, changedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	s.apply(change)
+	if command == models.ProcessingCommandRunOnce {
+		s.controller.SetState(models.ProcessingStateRunOnce)
+	}
+
+	return change, nil
+}
+
+// Watch polls the storage for processing state changes until the context is canceled.
+func (s *Syncer) Watch(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Load(ctx); err != nil {
+				log.Printf("failed to load processing state: %v\n", err)
+			}
+		case <-ctx.Done():
+			log.Printf("processing state syncer is stopped\n")
+			return
+		}
+	}
+}
+
+// apply updates the controller if the change is newer than the last applied one.
+func (s *Syncer) apply(change *models.ProcessingStateChange) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if change.ID <= s.lastChangeID {
+		return
+	}
+	s.lastChangeID = change.ID
+
+	if s.controller.State() != change.State {
+		log.Printf("message processing is %s by %s\n", change.State, change.ChangedBy)
+		s.controller.SetState(change.State)
+	}
+}
@@ -2,43 +2,86 @@ package pubsub
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/mehmetalisavas/message-sender/internal/models"
 	"github.com/mehmetalisavas/message-sender/internal/service"
+	"github.com/mehmetalisavas/message-sender/pkg/phonenumber"
 	"github.com/mehmetalisavas/message-sender/pkg/services/notification"
 )
 
 var _ Consumer = (*MessageConsumer)(nil)
 
+// deliveryMarkerTTL is how long the delivery of a message is remembered, it must exceed
+// the time after which a message stuck in processing is picked up again.
+const deliveryMarkerTTL = 24 * time.Hour
+
 type MessageConsumer struct {
 	storageService      service.Storage
 	messageBus          *MessageBus
-	notificationService notification.NotificationSender
+	notificationSenders *notification.Registry
 	cacheService        service.CacheStore
+	defaultCountryCode  string
+	deliveryTimeout     time.Duration
+	dedupeWindow        time.Duration
+	visibilityTimeout   time.Duration
+	statusWriter        *StatusWriter
 }
 
 // NewMessageConsumer creates a new MessageConsumer instance.
-func NewMessageConsumer(storageService service.Storage, messageBus *MessageBus, notificationService notification.NotificationSender, cacheService service.CacheStore) *MessageConsumer {
+// Messages are sent through the sender registered for their channel, SMS recipients stored in
+// national format are normalized with defaultCountryCode. Messages are marked as expired when
+// they can't be delivered within deliveryTimeout, retries included. Zero means no timeout.
+// Messages with the same recipient and content as one processed within dedupeWindow are marked
+// as deduplicated, zero disables it. The lease of a message is extended by visibilityTimeout
+// while it is processed, so that it isn't reclaimed by another instance during a long send.
+func NewMessageConsumer(storageService service.Storage, messageBus *MessageBus, notificationSenders *notification.Registry, cacheService service.CacheStore, defaultCountryCode string, deliveryTimeout, dedupeWindow, visibilityTimeout time.Duration) *MessageConsumer {
 	return &MessageConsumer{
 		storageService:      storageService,
 		messageBus:          messageBus,
-		notificationService: notificationService,
+		notificationSenders: notificationSenders,
 		cacheService:        cacheService,
+		defaultCountryCode:  defaultCountryCode,
+		deliveryTimeout:     deliveryTimeout,
+		dedupeWindow:        dedupeWindow,
+		visibilityTimeout:   visibilityTimeout,
+		statusWriter:        NewStatusWriter(storageService, defaultStatusBatchSize, defaultStatusFlushInterval),
 	}
 }
 
-// Consume consumes messages from the message bus.
+// Consume consumes messages from the message bus until ctx is done.
+// The statuses of the processed messages are written in batches, the remaining ones are
+// flushed once the workers are stopped.
 func (mc *MessageConsumer) Consume(ctx context.Context, workerCount int) error {
 	messageChannel, exists := mc.messageBus.GetChannel(MessageSenderTopic)
 	if !exists {
 		return ErrChannelNotFound
 	}
 
+	// The writer outlives ctx, so that the updates of the last messages are still written.
+	writerCtx, stopWriter := context.WithCancel(context.Background())
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		mc.statusWriter.Run(writerCtx)
+	}()
+
+	var wg sync.WaitGroup
 	for i := 0; i < workerCount; i++ {
-		go mc.worker(ctx, messageChannel)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mc.worker(ctx, messageChannel)
+		}()
 	}
+	wg.Wait()
+
+	stopWriter()
+	<-writerDone
 
 	return nil
 }
@@ -70,20 +113,163 @@ func (mc *MessageConsumer) worker(ctx context.Context, ch chan interface{}) erro
 
 // processMessage simulates sending a message
 func (mc *MessageConsumer) processMessage(ctx context.Context, msg models.Message) error {
-	requestSendingTime := time.Now()
-	resp, err := mc.notificationService.Send(ctx, msg.Recipient, msg.Content)
+	// The message may have waited in the bus since the producer last extended its lease.
+	if mc.visibilityTimeout > 0 {
+		err := mc.storageService.ExtendMessageLease(ctx, msg.ID, msg.LeaseToken, mc.visibilityTimeout)
+		if errors.Is(err, models.ErrLeaseLost) {
+			log.Printf("lease of message id:%d is lost before it is processed, skipping it\n", msg.ID)
+			return nil
+		}
+		if err != nil {
+			log.Printf("failed to extend lease of message id:%d: %v\n", msg.ID, err)
+		}
+	}
+
+	stopExtending := mc.extendLease(ctx, msg)
+	defer stopExtending()
+
+	channel := msg.Channel
+	if channel == "" {
+		channel = models.MessageChannelSMS
+	}
+
+	// Recipients are normalized when messages are created, this guards against rows inserted around
+	// the API and rows stored in national format before normalization was introduced.
+	recipient := msg.Recipient
+	if channel == models.MessageChannelSMS {
+		normalized, err := phonenumber.Normalize(msg.Recipient, mc.defaultCountryCode)
+		if err != nil {
+			log.Printf("failed to process message id:%d: %v\n", msg.ID, err)
+			mc.release(msg, models.MessageStatusFailed, err.Error())
+			return nil
+		}
+		recipient = normalized
+	}
+
+	// A message that is processed again after a crash may have been sent already.
+	marker, err := mc.cacheService.GetDeliveryMarker(ctx, msg.ID)
 	if err != nil {
-		log.Printf("failed to process message id:%d: %v\n", msg.ID, err)
-		err := mc.storageService.UpdateMessageStatus(ctx, msg.ID, models.MessageStatusFailed)
+		log.Printf("failed to get delivery marker of message id:%d: %v\n", msg.ID, err)
 		return err
 	}
+	if marker != nil && marker.State == models.DeliveryStateSent {
+		log.Printf("message id:%d was already sent, reconciling its status\n", msg.ID)
+		resp := &notification.NotificationResponse{MessageID: marker.ProviderMessageID, Provider: marker.Provider}
+		return mc.markSent(ctx, msg, resp, marker.SentAt, "already sent before the message was reclaimed")
+	}
 
-	err = mc.storageService.UpdateMessageStatus(ctx, msg.ID, models.MessageStatusSent)
+	suppressed, err := mc.storageService.IsSuppressed(ctx, recipient)
 	if err != nil {
-		log.Printf("failed to update message status id:%d: %v\n", msg.ID, err)
+		log.Printf("failed to check suppression of message id:%d: %v\n", msg.ID, err)
 		return err
 	}
-	err = mc.cacheService.CacheMessage(ctx, resp.MessageID, requestSendingTime)
+	if suppressed {
+		log.Printf("message id:%d is suppressed, the recipient opted out\n", msg.ID)
+		mc.release(msg, models.MessageStatusSuppressed, "recipient opted out")
+		return nil
+	}
+
+	sent := false
+	if mc.dedupeWindow > 0 {
+		originalID, err := mc.cacheService.ReserveDedupeKey(ctx, recipient, msg.Content, msg.ID, mc.dedupeWindow)
+		if err != nil {
+			// Sending a duplicate is better than not sending at all while the cache is unavailable.
+			log.Printf("failed to check duplicates of message id:%d: %v\n", msg.ID, err)
+		}
+		if originalID != 0 {
+			log.Printf("message id:%d is a duplicate of message id:%d\n", msg.ID, originalID)
+			mc.release(msg, models.MessageStatusDeduplicated, fmt.Sprintf("duplicate of message %d", originalID))
+			return nil
+		}
+
+		// A message that isn't delivered must not mark a legitimate resend within the window as a duplicate.
+		if err == nil {
+			defer func() {
+				if sent {
+					return
+				}
+				if err := mc.cacheService.ReleaseDedupeKey(context.WithoutCancel(ctx), recipient, msg.Content, msg.ID); err != nil {
+					log.Printf("failed to release the dedupe key of message id:%d: %v\n", msg.ID, err)
+				}
+			}()
+		}
+	}
+
+	sendCtx := ctx
+	if mc.deliveryTimeout > 0 {
+		var cancel context.CancelFunc
+		sendCtx, cancel = context.WithTimeout(ctx, mc.deliveryTimeout)
+		defer cancel()
+	}
+
+	// Providers drop a request with an idempotency key they already handled, so a message
+	// that was in flight when a worker crashed is not delivered twice when it is sent again.
+	err = mc.cacheService.SetDeliveryMarker(ctx, msg.ID, models.DeliveryMarker{State: models.DeliveryStateInFlight}, deliveryMarkerTTL)
+	if err != nil {
+		log.Printf("failed to set delivery marker of message id:%d: %v\n", msg.ID, err)
+		return err
+	}
+	sendCtx = notification.WithIdempotencyKey(sendCtx, fmt.Sprintf("message-%d", msg.ID))
+
+	requestSendingTime := time.Now()
+	resp, err := mc.notificationSenders.Send(sendCtx, notification.Channel(channel), recipient, msg.Content)
+
+	// The provider is unavailable, keep the message pending instead of failing it and
+	// hold the worker until the breaker lets requests through again.
+	var openErr *notification.CircuitOpenError
+	if errors.As(err, &openErr) {
+		log.Printf("message id:%d is left pending: %v\n", msg.ID, err)
+		mc.release(msg, models.MessageStatusPending, err.Error())
+
+		select {
+		case <-time.After(openErr.RetryAfter):
+		case <-ctx.Done():
+		}
+		return nil
+	}
+	// A send interrupted by shutdown says nothing about the message, it is sent again later.
+	if err != nil && ctx.Err() != nil {
+		log.Printf("message id:%d is left pending, the consumer is stopped: %v\n", msg.ID, err)
+		mc.release(msg, models.MessageStatusPending, "consumer stopped during the send")
+		return nil
+	}
+	if err != nil && errors.Is(sendCtx.Err(), context.DeadlineExceeded) {
+		log.Printf("message id:%d is expired: %v\n", msg.ID, err)
+		mc.release(msg, models.MessageStatusExpired, err.Error())
+		return nil
+	}
+	if err != nil {
+		log.Printf("failed to process message id:%d: %v\n", msg.ID, err)
+		mc.release(msg, models.MessageStatusFailed, err.Error())
+		return nil
+	}
+
+	sent = true
+	marker = &models.DeliveryMarker{
+		State:             models.DeliveryStateSent,
+		ProviderMessageID: resp.MessageID,
+		Provider:          resp.Provider,
+		SentAt:            requestSendingTime,
+	}
+	if err := mc.cacheService.SetDeliveryMarker(ctx, msg.ID, *marker, deliveryMarkerTTL); err != nil {
+		log.Printf("failed to set delivery marker of message id:%d: %v\n", msg.ID, err)
+	}
+
+	return mc.markSent(ctx, msg, resp, requestSendingTime, "accepted by the provider")
+}
+
+// markSent records the message as sent by the provider of resp.
+func (mc *MessageConsumer) markSent(ctx context.Context, msg models.Message, resp *notification.NotificationResponse, sendTime time.Time, reason string) error {
+	mc.writeStatus(models.MessageStatusUpdate{
+		ID:         msg.ID,
+		LeaseToken: msg.LeaseToken,
+		Status:     models.MessageStatusSent,
+		Provider:   resp.Provider,
+		Actor:      models.MessageActorConsumer,
+		Reason:     reason,
+	})
+
+	err := mc.cacheService.CacheMessage(ctx, resp.MessageID, sendTime)
 	if err != nil {
 		log.Printf("failed to cache message id:%s: %v\n", resp.MessageID, err)
 		return err
@@ -93,3 +279,56 @@ func (mc *MessageConsumer) processMessage(ctx context.Context, msg models.Messag
 
 	return nil
 }
+
+// release queues the status of the message to be written with the reason of the transition, which ends its lease.
+func (mc *MessageConsumer) release(msg models.Message, status models.MessageStatus, reason string) {
+	mc.writeStatus(models.MessageStatusUpdate{
+		ID:         msg.ID,
+		LeaseToken: msg.LeaseToken,
+		Status:     status,
+		Actor:      models.MessageActorConsumer,
+		Reason:     reason,
+	})
+}
+
+// writeStatus queues the update to the status writer. An update that can't be queued is left to
+// the lease: the message is processed again once it expires.
+func (mc *MessageConsumer) writeStatus(update models.MessageStatusUpdate) {
+	if !mc.statusWriter.Write(update) {
+		log.Printf("status %s of message id:%d is not written, the status writer is stopped\n", update.Status, update.ID)
+	}
+}
+
+// extendLease keeps extending the lease of the message until the returned function is called,
+// or the lease is lost.
+func (mc *MessageConsumer) extendLease(ctx context.Context, msg models.Message) func() {
+	if mc.visibilityTimeout <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(mc.visibilityTimeout / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				err := mc.storageService.ExtendMessageLease(ctx, msg.ID, msg.LeaseToken, mc.visibilityTimeout)
+				if errors.Is(err, models.ErrLeaseLost) {
+					log.Printf("lease of message id:%d is lost\n", msg.ID)
+					return
+				}
+				if err != nil {
+					log.Printf("failed to extend lease of message id:%d: %v\n", msg.ID, err)
+				}
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
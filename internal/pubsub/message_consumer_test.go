@@ -0,0 +1,186 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mehmetalisavas/message-sender/internal/models"
+	"github.com/mehmetalisavas/message-sender/internal/service"
+	"github.com/mehmetalisavas/message-sender/pkg/services/notification"
+)
+
+// consumerStorage answers the suppression checks of a MessageConsumer.
+type consumerStorage struct {
+	service.Storage
+
+	suppressed map[string]bool
+}
+
+func (s *consumerStorage) IsSuppressed(ctx context.Context, recipient string) (bool, error) {
+	return s.suppressed[recipient], nil
+}
+
+// consumerCache keeps the delivery markers and dedupe keys of a MessageConsumer in memory.
+type consumerCache struct {
+	service.CacheStore
+
+	mu       sync.Mutex
+	markers  map[int]models.DeliveryMarker
+	released []int
+}
+
+func (c *consumerCache) CacheMessage(ctx context.Context, messageId string, sendTime time.Time) error {
+	return nil
+}
+
+func (c *consumerCache) ReserveDedupeKey(ctx context.Context, recipient, content string, messageID int, window time.Duration) (int, error) {
+	return 0, nil
+}
+
+func (c *consumerCache) ReleaseDedupeKey(ctx context.Context, recipient, content string, messageID int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.released = append(c.released, messageID)
+	return nil
+}
+
+func (c *consumerCache) GetDeliveryMarker(ctx context.Context, messageID int) (*models.DeliveryMarker, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	marker, ok := c.markers[messageID]
+	if !ok {
+		return nil, nil
+	}
+	return &marker, nil
+}
+
+func (c *consumerCache) SetDeliveryMarker(ctx context.Context, messageID int, marker models.DeliveryMarker, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.markers[messageID] = marker
+	return nil
+}
+
+// sendFunc is a NotificationSender calling itself.
+type sendFunc func(ctx context.Context, recipient, content string) (*notification.NotificationResponse, error)
+
+func (f sendFunc) Send(ctx context.Context, recipient, content string) (*notification.NotificationResponse, error) {
+	return f(ctx, recipient, content)
+}
+
+// blockingSend waits until the send is cancelled or times out.
+func blockingSend(ctx context.Context, recipient, content string) (*notification.NotificationResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestProcessMessage(t *testing.T) {
+	tests := []struct {
+		name       string
+		marker     *models.DeliveryMarker
+		suppressed bool
+		send       sendFunc
+		// stopDuringSend cancels the consumer's context while the message is being sent.
+		stopDuringSend bool
+		wantStatus     models.MessageStatus
+		wantProvider   string
+		wantSends      int
+		wantReleased   bool
+	}{
+		{
+			name: "sent",
+			send: func(ctx context.Context, recipient, content string) (*notification.NotificationResponse, error) {
+				return &notification.NotificationResponse{MessageID: "provider-1", Provider: "primary"}, nil
+			},
+			wantStatus:   models.MessageStatusSent,
+			wantProvider: "primary",
+			wantSends:    1,
+		},
+		{
+			name: "failed",
+			send: func(ctx context.Context, recipient, content string) (*notification.NotificationResponse, error) {
+				return nil, errors.New("invalid number")
+			},
+			wantStatus:   models.MessageStatusFailed,
+			wantSends:    1,
+			wantReleased: true,
+		},
+		{
+			name:         "expired",
+			send:         blockingSend,
+			wantStatus:   models.MessageStatusExpired,
+			wantSends:    1,
+			wantReleased: true,
+		},
+		{
+			name:           "stopped during the send",
+			send:           blockingSend,
+			stopDuringSend: true,
+			wantStatus:     models.MessageStatusPending,
+			wantSends:      1,
+			wantReleased:   true,
+		},
+		{
+			name:         "already sent",
+			marker:       &models.DeliveryMarker{State: models.DeliveryStateSent, ProviderMessageID: "provider-1", Provider: "backup", SentAt: time.Now()},
+			wantStatus:   models.MessageStatusSent,
+			wantProvider: "backup",
+		},
+		{
+			name:       "suppressed",
+			suppressed: true,
+			wantStatus: models.MessageStatusSuppressed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			msg := models.Message{ID: 1, Recipient: "+905555555555", Content: "hello", Channel: models.MessageChannelSMS, LeaseToken: "token"}
+			cache := &consumerCache{markers: make(map[int]models.DeliveryMarker)}
+			if tt.marker != nil {
+				cache.markers[msg.ID] = *tt.marker
+			}
+			storage := &consumerStorage{suppressed: map[string]bool{msg.Recipient: tt.suppressed}}
+
+			sends := 0
+			senders := notification.NewRegistry()
+			senders.Register(notification.ChannelSMS, sendFunc(func(sendCtx context.Context, recipient, content string) (*notification.NotificationResponse, error) {
+				sends++
+				if tt.stopDuringSend {
+					cancel()
+				}
+				return tt.send(sendCtx, recipient, content)
+			}))
+
+			consumer := NewMessageConsumer(storage, NewMessageBus(), senders, cache, "90", 20*time.Millisecond, time.Minute, 0)
+			if err := consumer.processMessage(ctx, msg); err != nil {
+				t.Fatalf("processMessage() error = %v", err)
+			}
+
+			var update models.MessageStatusUpdate
+			select {
+			case update = <-consumer.statusWriter.updates:
+			default:
+				t.Fatalf("processMessage() didn't write a status")
+			}
+			if update.ID != msg.ID || update.LeaseToken != msg.LeaseToken || update.Status != tt.wantStatus || update.Provider != tt.wantProvider {
+				t.Errorf("processMessage() wrote %+v, want status %s by provider %q", update, tt.wantStatus, tt.wantProvider)
+			}
+			if sends != tt.wantSends {
+				t.Errorf("expected %d sends, got %d", tt.wantSends, sends)
+			}
+			if released := len(cache.released) > 0; released != tt.wantReleased {
+				t.Errorf("dedupe key released = %v, want %v", released, tt.wantReleased)
+			}
+		})
+	}
+}
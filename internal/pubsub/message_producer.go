@@ -6,7 +6,8 @@ import (
 	"log"
 	"time"
 
-	"github.com/mehmetalisavas/message-sender/config"
+	"github.com/mehmetalisavas/message-sender/internal/models"
+	"github.com/mehmetalisavas/message-sender/internal/processing"
 	"github.com/mehmetalisavas/message-sender/internal/service"
 )
 
@@ -17,28 +18,42 @@ var ErrChannelNotFound = errors.New("channel not found")
 
 const MessageSenderTopic = "message-sender"
 
+// defaultBatchSize is the number of pending messages fetched on every tick.
+const defaultBatchSize = 2
+
 type MessageProducer struct {
-	cfg            *config.Config
+	controller     *processing.Controller
 	storageService service.Storage
 	messageBus     *MessageBus
-	// intervalInSec represents the interval in seconds to produce messages.
+	// intervalInSec represents the regular interval in seconds to produce messages.
 	intervalInSec int
+	// visibilityTimeout is how long fetched messages are leased to the consumers.
+	visibilityTimeout time.Duration
 }
 
 // NewMessageProducer creates a new MessageProducer instance.
-func NewMessageProducer(cfg *config.Config, storageService service.Storage, messageBus *MessageBus, interval int) *MessageProducer {
+// Fetched messages are leased for visibilityTimeout, they are fetched again if they are still
+// processing once it expires and the consumer didn't extend the lease.
+func NewMessageProducer(controller *processing.Controller, storageService service.Storage, messageBus *MessageBus, interval int, visibilityTimeout time.Duration) *MessageProducer {
 	return &MessageProducer{
-		cfg:            cfg,
-		storageService: storageService,
-		messageBus:     messageBus,
-		intervalInSec:  interval,
+		controller:        controller,
+		storageService:    storageService,
+		messageBus:        messageBus,
+		intervalInSec:     interval,
+		visibilityTimeout: visibilityTimeout,
 	}
 }
 
 // Produce produces messages to the message queue.
+// It polls the storage adaptively: immediately while there is a backlog and the bus has capacity,
+// in the regular interval otherwise, backing off exponentially while nothing is pending.
+// Besides polling, it reacts to processing state changes and run-now triggers immediately.
+// Triggered runs don't reset the polling timer, so the regular cadence is kept.
 func (mp *MessageProducer) Produce(ctx context.Context) error {
-	ticker := time.NewTicker(time.Duration(mp.intervalInSec) * time.Second)
-	defer ticker.Stop()
+	interval := time.Duration(mp.intervalInSec) * time.Second
+	delay := interval
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
 
 	messageChannel, exists := mp.messageBus.GetChannel(MessageSenderTopic)
 	if !exists {
@@ -46,29 +61,138 @@ func (mp *MessageProducer) Produce(ctx context.Context) error {
 		return ErrChannelNotFound
 	}
 
+	recordPolling(PollingStateRegular, delay)
+
+	changed := mp.controller.Changed()
 	for {
 		select {
-		case <-ticker.C:
-			if !mp.cfg.IsMessageProcessing {
-				log.Printf("message processing is stopped\n")
+		case <-timer.C:
+			if mp.controller.State() != models.ProcessingStateStarted {
+				log.Printf("message processing is %s\n", mp.controller.State())
+				delay = interval
+				recordPolling(PollingStateStopped, delay)
+				timer.Reset(delay)
 				continue
 			}
-			// Get pending messages from storage.
-			log.Printf("getting pending messages from storage\n")
 
-			messages, err := mp.storageService.GetPendingMessages(ctx, 2)
-			if err != nil {
-				log.Printf("failed to get pending messages from storage: %v\n", err)
+			fetched := mp.produceBatch(ctx, messageChannel, defaultBatchSize)
+
+			var state PollingState
+			delay, state = nextPollDelay(delay, interval, fetched, defaultBatchSize, len(messageChannel), cap(messageChannel))
+			recordPolling(state, delay)
+			timer.Reset(delay)
+
+		case <-mp.controller.Triggered():
+			batchSize := mp.controller.TakeTriggerBatchSize()
+			if batchSize <= 0 {
+				batchSize = defaultBatchSize
+			}
+
+			state := mp.controller.State()
+			if state != models.ProcessingStateStarted && state != models.ProcessingStateRunOnce {
+				log.Printf("message processing is %s, ignoring run-now trigger\n", state)
 				continue
 			}
+			mp.produceBatch(ctx, messageChannel, batchSize)
 
-			for _, message := range messages {
-				// Publish message to the message queue.
-				messageChannel <- message
+		case <-changed:
+			changed = mp.controller.Changed()
+
+			switch mp.controller.State() {
+			case models.ProcessingStateStarted:
+				mp.produceBatch(ctx, messageChannel, defaultBatchSize)
+			case models.ProcessingStateRunOnce:
+				mp.produceBatch(ctx, messageChannel, defaultBatchSize)
+				mp.controller.CompareAndSetState(models.ProcessingStateRunOnce, models.ProcessingStateStopped)
 			}
+
 		case <-ctx.Done():
 			log.Printf("message producer is stopped\n")
 			return nil
 		}
 	}
 }
+
+// produceBatch fetches up to batchSize pending messages and publishes them to the message channel.
+// If processing is stopped in the middle of the batch, the messages that are not published
+// yet are released back to pending. A pause lets the current batch to be published.
+// The leases of the messages waiting to be published are extended, so that they don't expire
+// while the workers are busy with a large batch.
+// It returns the number of fetched messages.
+func (mp *MessageProducer) produceBatch(ctx context.Context, messageChannel chan interface{}, batchSize int) int {
+	// Get pending messages from storage.
+	log.Printf("getting pending messages from storage\n")
+
+	messages, err := mp.storageService.GetPendingMessages(ctx, batchSize, mp.visibilityTimeout)
+	if err != nil {
+		log.Printf("failed to get pending messages from storage: %v\n", err)
+		return 0
+	}
+	producerMetrics.Add("fetches_total", 1)
+	producerMetrics.Add("messages_fetched_total", int64(len(messages)))
+	producerLastFetched.Set(int64(len(messages)))
+
+	var extend <-chan time.Time
+	if mp.visibilityTimeout > 0 {
+		ticker := time.NewTicker(mp.visibilityTimeout / 3)
+		defer ticker.Stop()
+		extend = ticker.C
+	}
+
+	changed := mp.controller.Changed()
+	undispatched := messages
+	for len(undispatched) > 0 {
+		select {
+		// Publish message to the message queue.
+		case messageChannel <- undispatched[0]:
+			undispatched = undispatched[1:]
+		case <-extend:
+			undispatched = mp.extendLeases(ctx, undispatched)
+		case <-changed:
+			changed = mp.controller.Changed()
+			if mp.controller.State() == models.ProcessingStateStopped {
+				mp.releaseMessages(ctx, undispatched)
+				return len(messages)
+			}
+		case <-ctx.Done():
+			return len(messages)
+		}
+	}
+
+	return len(messages)
+}
+
+// extendLeases extends the leases of the given messages and returns the ones that are still leased.
+// A message whose lease is lost is processed by another instance, it must not be published.
+func (mp *MessageProducer) extendLeases(ctx context.Context, messages []models.Message) []models.Message {
+	leased := make([]models.Message, 0, len(messages))
+	for _, message := range messages {
+		err := mp.storageService.ExtendMessageLease(ctx, message.ID, message.LeaseToken, mp.visibilityTimeout)
+		if errors.Is(err, models.ErrLeaseLost) {
+			log.Printf("lease of message id:%d is lost before it is published\n", message.ID)
+			continue
+		}
+		if err != nil {
+			log.Printf("failed to extend lease of message id:%d: %v\n", message.ID, err)
+		}
+		leased = append(leased, message)
+	}
+	return leased
+}
+
+// releaseMessages marks the given messages as pending, so they are picked up again once processing starts.
+func (mp *MessageProducer) releaseMessages(ctx context.Context, messages []models.Message) {
+	for _, message := range messages {
+		err := mp.storageService.ReleaseMessage(ctx, models.MessageStatusUpdate{
+			ID:         message.ID,
+			LeaseToken: message.LeaseToken,
+			Status:     models.MessageStatusPending,
+			Actor:      models.MessageActorProducer,
+			Reason:     "processing stopped",
+		})
+		if err != nil {
+			log.Printf("failed to release message id:%d: %v\n", message.ID, err)
+		}
+	}
+	log.Printf("message processing is stopped, %d messages are released\n", len(messages))
+}
@@ -0,0 +1,84 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mehmetalisavas/message-sender/internal/models"
+	"github.com/mehmetalisavas/message-sender/internal/processing"
+	"github.com/mehmetalisavas/message-sender/internal/service"
+)
+
+// leaseStorage returns the pending messages once and records the lease extensions.
+type leaseStorage struct {
+	service.Storage
+
+	mu       sync.Mutex
+	pending  []models.Message
+	extended map[int]int
+	// lost are the messages whose lease is taken by another instance.
+	lost map[int]bool
+}
+
+func (s *leaseStorage) GetPendingMessages(ctx context.Context, limit int, visibilityTimeout time.Duration) ([]models.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	messages := s.pending
+	s.pending = nil
+	return messages, nil
+}
+
+func (s *leaseStorage) ExtendMessageLease(ctx context.Context, id int, leaseToken string, visibilityTimeout time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.lost[id] {
+		return models.ErrLeaseLost
+	}
+	s.extended[id]++
+	return nil
+}
+
+func (s *leaseStorage) Extended(id int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.extended[id]
+}
+
+func TestProduceBatch_ExtendsLeasesOfUndispatchedMessages(t *testing.T) {
+	storage := &leaseStorage{
+		pending:  []models.Message{{ID: 1}, {ID: 2}, {ID: 3}},
+		extended: make(map[int]int),
+		lost:     map[int]bool{3: true},
+	}
+	producer := NewMessageProducer(processing.NewController(models.ProcessingStateStarted), storage, NewMessageBus(), 1, 30*time.Millisecond)
+
+	// Nobody reads the channel, the first message waits to be published along with the others.
+	ch := make(chan interface{})
+	done := make(chan int)
+	go func() { done <- producer.produceBatch(context.Background(), ch, 3) }()
+
+	deadline := time.Now().Add(time.Second)
+	for storage.Extended(2) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if storage.Extended(1) == 0 || storage.Extended(2) == 0 {
+		t.Fatalf("expected the leases of the undispatched messages to be extended, got %v", storage.extended)
+	}
+
+	// The message whose lease is lost isn't published.
+	var published []int
+	for len(published) < 2 {
+		published = append(published, (<-ch).(models.Message).ID)
+	}
+	if fetched := <-done; fetched != 3 {
+		t.Errorf("produceBatch() = %d, want 3", fetched)
+	}
+	if published[0] != 1 || published[1] != 2 {
+		t.Errorf("expected messages 1 and 2 to be published, got %v", published)
+	}
+}
@@ -0,0 +1,72 @@
+package pubsub
+
+import (
+	"expvar"
+	"time"
+)
+
+// maxBackoffFactor bounds the polling backoff to maxBackoffFactor times the regular interval.
+const maxBackoffFactor = 8
+
+// saturatedPollInterval is the delay before polling again when there is a backlog but the message bus is full.
+const saturatedPollInterval = time.Second
+
+// PollingState represents how the producer polls the storage for pending messages.
+type PollingState string
+
+const (
+	// PollingStateBacklog polls again immediately, the last fetch was full and the bus has capacity.
+	PollingStateBacklog PollingState = "backlog"
+	// PollingStateSaturated polls again shortly, the last fetch was full but the bus has no capacity.
+	PollingStateSaturated PollingState = "saturated"
+	// PollingStateRegular polls in the regular interval.
+	PollingStateRegular PollingState = "regular"
+	// PollingStateBackoff polls in an exponentially growing interval, nothing was pending.
+	PollingStateBackoff PollingState = "backoff"
+	// PollingStateStopped polls in the regular interval while message processing is not started.
+	PollingStateStopped PollingState = "stopped"
+)
+
+// Producer metrics are exposed through expvar under "message_producer".
+var (
+	producerMetrics      = expvar.NewMap("message_producer")
+	producerPollingState = new(expvar.String)
+	producerPollDelay    = new(expvar.Float)
+	producerLastFetched  = new(expvar.Int)
+)
+
+func init() {
+	producerMetrics.Set("polling_state", producerPollingState)
+	producerMetrics.Set("poll_delay_seconds", producerPollDelay)
+	producerMetrics.Set("last_fetched", producerLastFetched)
+}
+
+// nextPollDelay returns the delay before the next poll according to the result of the last fetch.
+// previous is the delay used before the last fetch and interval is the regular polling interval.
+func nextPollDelay(previous, interval time.Duration, fetched, batchSize, busLen, busCap int) (time.Duration, PollingState) {
+	switch {
+	case fetched >= batchSize && busLen < busCap:
+		return 0, PollingStateBacklog
+	case fetched >= batchSize:
+		return saturatedPollInterval, PollingStateSaturated
+	case fetched > 0:
+		return interval, PollingStateRegular
+	}
+
+	if previous < interval {
+		return interval, PollingStateBackoff
+	}
+
+	next := previous * 2
+	if max := interval * maxBackoffFactor; next > max {
+		next = max
+	}
+
+	return next, PollingStateBackoff
+}
+
+// recordPolling publishes the polling state of the producer.
+func recordPolling(state PollingState, delay time.Duration) {
+	producerPollingState.Set(string(state))
+	producerPollDelay.Set(delay.Seconds())
+}
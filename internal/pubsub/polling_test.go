@@ -0,0 +1,35 @@
+package pubsub
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextPollDelay(t *testing.T) {
+	interval := 10 * time.Second
+
+	tests := []struct {
+		name      string
+		previous  time.Duration
+		fetched   int
+		busLen    int
+		wantDelay time.Duration
+		wantState PollingState
+	}{
+		{"full fetch with bus capacity", interval, 2, 1, 0, PollingStateBacklog},
+		{"full fetch with full bus", interval, 2, 2, saturatedPollInterval, PollingStateSaturated},
+		{"partial fetch", 40 * time.Second, 1, 0, interval, PollingStateRegular},
+		{"empty fetch after backlog", 0, 0, 0, interval, PollingStateBackoff},
+		{"empty fetch doubles the delay", interval, 0, 0, 20 * time.Second, PollingStateBackoff},
+		{"empty fetch is capped", 60 * time.Second, 0, 0, 80 * time.Second, PollingStateBackoff},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, state := nextPollDelay(tt.previous, interval, tt.fetched, 2, tt.busLen, 2)
+			if delay != tt.wantDelay || state != tt.wantState {
+				t.Errorf("nextPollDelay() = (%v, %s), want (%v, %s)", delay, state, tt.wantDelay, tt.wantState)
+			}
+		})
+	}
+}
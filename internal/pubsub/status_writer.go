@@ -0,0 +1,174 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"log"
+	"time"
+
+	"github.com/mehmetalisavas/message-sender/internal/models"
+	"github.com/mehmetalisavas/message-sender/internal/service"
+	"github.com/mehmetalisavas/message-sender/pkg/retry"
+)
+
+const (
+	// defaultStatusBatchSize is the number of status updates that triggers a flush before the interval.
+	defaultStatusBatchSize = 100
+	// defaultStatusFlushInterval is the longest time a status update waits to be written.
+	defaultStatusFlushInterval = time.Second
+	// statusFlushTimeout bounds a flush, its retries included.
+	statusFlushTimeout = 30 * time.Second
+)
+
+// statusFlushRetryConfig retries a batch that couldn't be written, e.g. while the database fails over,
+// instead of dropping it and leaving its messages processing until their leases expire.
+var statusFlushRetryConfig = retry.Config{
+	MaxRetries:     5,
+	InitialBackoff: 200 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+	BackoffFactor:  2,
+}
+
+// Status writer metrics are exposed through expvar under "status_writer".
+var statusWriterMetrics = expvar.NewMap("status_writer")
+
+// StatusWriter coalesces the status updates of all workers into periodic multi-row updates,
+// so that completing a message doesn't cost a database round trip of its own.
+type StatusWriter struct {
+	storageService service.Storage
+	batchSize      int
+	flushInterval  time.Duration
+	updates        chan models.MessageStatusUpdate
+	// done is closed when Run returns, so that writers don't block on a queue nobody reads.
+	done chan struct{}
+}
+
+// NewStatusWriter creates a new StatusWriter that writes up to batchSize updates at once,
+// at least every flushInterval.
+func NewStatusWriter(storageService service.Storage, batchSize int, flushInterval time.Duration) *StatusWriter {
+	return &StatusWriter{
+		storageService: storageService,
+		batchSize:      batchSize,
+		flushInterval:  flushInterval,
+		updates:        make(chan models.MessageStatusUpdate, batchSize),
+		done:           make(chan struct{}),
+	}
+}
+
+// Write queues the update, it blocks while the queue is full. It returns false without
+// queuing the update once Run has returned.
+func (sw *StatusWriter) Write(update models.MessageStatusUpdate) bool {
+	select {
+	case sw.updates <- update:
+		return true
+	case <-sw.done:
+		return false
+	}
+}
+
+// Run writes the queued updates until ctx is done, then flushes the remaining ones.
+// Writers must be stopped before ctx is done, otherwise their last updates may be lost.
+// Run must be called once.
+func (sw *StatusWriter) Run(ctx context.Context) {
+	defer close(sw.done)
+
+	ticker := time.NewTicker(sw.flushInterval)
+	defer ticker.Stop()
+
+	batch := newStatusBatch(sw.batchSize)
+	for {
+		select {
+		case update := <-sw.updates:
+			batch.add(update)
+			if batch.len() >= sw.batchSize {
+				sw.flush(batch)
+				batch = newStatusBatch(sw.batchSize)
+			}
+		case <-ticker.C:
+			if batch.len() > 0 {
+				sw.flush(batch)
+				batch = newStatusBatch(sw.batchSize)
+			}
+		case <-ctx.Done():
+		drain:
+			for {
+				select {
+				case update := <-sw.updates:
+					batch.add(update)
+				default:
+					break drain
+				}
+			}
+			if batch.len() > 0 {
+				sw.flush(batch)
+			}
+			log.Println("status writer is stopped")
+			return
+		}
+	}
+}
+
+// flush writes the batch, retrying it while the storage fails. A flush isn't bound to the
+// context of Run, so that the batch isn't dropped halfway through its retries on shutdown.
+func (sw *StatusWriter) flush(batch *statusBatch) {
+	ctx, cancel := context.WithTimeout(context.Background(), statusFlushTimeout)
+	defer cancel()
+
+	updates := batch.updates
+	config := statusFlushRetryConfig
+	config.OnAttempt = func(attempt retry.Attempt) {
+		if attempt.Retry {
+			statusWriterMetrics.Add("flush_retries_total", 1)
+			log.Printf("failed to write %d message statuses (attempt %d), retrying in %v: %v\n", len(updates), attempt.Number, attempt.Delay, attempt.Err)
+		}
+	}
+
+	skipped, err := retry.Do(ctx, func() (map[int]error, error) {
+		skipped, err := sw.storageService.UpdateMessageStatuses(ctx, updates)
+		if errors.Is(err, models.ErrInvalidMessageTransition) {
+			return nil, retry.Permanent(err)
+		}
+		return skipped, err
+	}, config)
+	statusWriterMetrics.Add("flushes_total", 1)
+	if err != nil {
+		statusWriterMetrics.Add("failed_updates_total", int64(len(updates)))
+		log.Printf("failed to write %d message statuses, they are processed again once their leases expire: %v\n", len(updates), err)
+		return
+	}
+
+	statusWriterMetrics.Add("updates_total", int64(len(updates)-len(skipped)))
+	statusWriterMetrics.Add("skipped_updates_total", int64(len(skipped)))
+	for _, u := range updates {
+		if err, ok := skipped[u.ID]; ok {
+			log.Printf("status %s of message id:%d is not written: %v\n", u.Status, u.ID, err)
+		}
+	}
+}
+
+// statusBatch keeps the latest update of every message in the order the messages were first updated.
+type statusBatch struct {
+	updates []models.MessageStatusUpdate
+	index   map[int]int
+}
+
+func newStatusBatch(size int) *statusBatch {
+	return &statusBatch{
+		updates: make([]models.MessageStatusUpdate, 0, size),
+		index:   make(map[int]int, size),
+	}
+}
+
+func (b *statusBatch) add(update models.MessageStatusUpdate) {
+	if i, ok := b.index[update.ID]; ok {
+		b.updates[i] = update
+		return
+	}
+	b.index[update.ID] = len(b.updates)
+	b.updates = append(b.updates, update)
+}
+
+func (b *statusBatch) len() int {
+	return len(b.updates)
+}
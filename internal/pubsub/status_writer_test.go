@@ -0,0 +1,145 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mehmetalisavas/message-sender/internal/models"
+	"github.com/mehmetalisavas/message-sender/internal/service"
+)
+
+// statusStorage records the status updates written by a StatusWriter.
+type statusStorage struct {
+	service.Storage
+
+	mu      sync.Mutex
+	batches [][]models.MessageStatusUpdate
+	// failures is the number of writes that fail before the updates are recorded.
+	failures int
+}
+
+func (s *statusStorage) UpdateMessageStatuses(ctx context.Context, updates []models.MessageStatusUpdate) (map[int]error, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failures > 0 {
+		s.failures--
+		return nil, errors.New("database is unavailable")
+	}
+	s.batches = append(s.batches, updates)
+	return nil, nil
+}
+
+func (s *statusStorage) Batches() [][]models.MessageStatusUpdate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.batches
+}
+
+func TestStatusWriter_FlushesFullBatches(t *testing.T) {
+	storage := &statusStorage{}
+	writer := NewStatusWriter(storage, 2, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		writer.Run(ctx)
+	}()
+
+	writer.Write(models.MessageStatusUpdate{ID: 1, Status: models.MessageStatusSent})
+	writer.Write(models.MessageStatusUpdate{ID: 2, Status: models.MessageStatusFailed})
+
+	deadline := time.Now().Add(time.Second)
+	for len(storage.Batches()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	batches := storage.Batches()
+	if len(batches) != 1 || len(batches[0]) != 2 {
+		t.Fatalf("expected a single batch of 2 updates, got %v", batches)
+	}
+}
+
+func TestStatusWriter_FlushesOnShutdown(t *testing.T) {
+	storage := &statusStorage{}
+	writer := NewStatusWriter(storage, 10, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		writer.Run(ctx)
+	}()
+
+	writer.Write(models.MessageStatusUpdate{ID: 1, Status: models.MessageStatusPending})
+	writer.Write(models.MessageStatusUpdate{ID: 2, Status: models.MessageStatusFailed})
+	// The latest update of a message wins.
+	writer.Write(models.MessageStatusUpdate{ID: 1, Status: models.MessageStatusSent, Provider: "primary"})
+	cancel()
+	<-done
+
+	batches := storage.Batches()
+	if len(batches) != 1 {
+		t.Fatalf("expected 1 batch, got %d", len(batches))
+	}
+	want := []models.MessageStatusUpdate{
+		{ID: 1, Status: models.MessageStatusSent, Provider: "primary"},
+		{ID: 2, Status: models.MessageStatusFailed},
+	}
+	if len(batches[0]) != len(want) {
+		t.Fatalf("expected %v, got %v", want, batches[0])
+	}
+	for i := range want {
+		if batches[0][i] != want[i] {
+			t.Errorf("update %d = %+v, want %+v", i, batches[0][i], want[i])
+		}
+	}
+}
+
+func TestStatusWriter_RetriesFailedFlushes(t *testing.T) {
+	storage := &statusStorage{failures: 2}
+	writer := NewStatusWriter(storage, 10, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		writer.Run(ctx)
+	}()
+
+	writer.Write(models.MessageStatusUpdate{ID: 1, Status: models.MessageStatusSent})
+	cancel()
+	<-done
+
+	batches := storage.Batches()
+	if len(batches) != 1 || len(batches[0]) != 1 {
+		t.Fatalf("expected the batch to be written after the failures, got %v", batches)
+	}
+}
+
+func TestStatusWriter_WriteAfterStop(t *testing.T) {
+	writer := NewStatusWriter(&statusStorage{}, 1, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	writer.Run(ctx)
+
+	// The queue holds a single update, the second write would block forever without a reader.
+	for i := 1; i <= 2; i++ {
+		written := make(chan bool)
+		go func() { written <- writer.Write(models.MessageStatusUpdate{ID: i, Status: models.MessageStatusSent}) }()
+
+		select {
+		case <-written:
+		case <-time.After(time.Second):
+			t.Fatalf("Write() blocked after the writer is stopped")
+		}
+	}
+}
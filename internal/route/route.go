@@ -1,6 +1,7 @@
 package route
 
 import (
+	"expvar"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -24,16 +25,41 @@ import (
 func Routers(api *api.Api) http.Handler {
 	r := mux.NewRouter()
 
-	// Process message command (start/stop)
+	// Process message command (start/stop/pause-after-current/run-once)
 	// @Summary Update message processing
-	// @Description Start or stop the message processing based on the command
+	// @Description Control the message processing based on the command
 	// @Accept json
 	// @Produce json
-	// @Param command query string true "Command: start or stop"
-	// @Success 200 {string} string "Message processing started/stopped"
+	// @Param command query string true "Command: start, stop, pause-after-current or run-once"
+	// @Success 200 {string} string "Message processing started/stopped/paused"
 	// @Failure 400 {string} string "Command is required or invalid command"
-	// @Router /process_message [get]
-	r.HandleFunc("/process_message", api.UpdateMessageProcessing).Methods("GET")
+	// @Router /process_message [post]
+	r.HandleFunc("/process_message", api.UpdateMessageProcessing).Methods("POST")
+
+	// Trigger message processing immediately
+	// @Summary Trigger message processing now
+	// @Description Make the producer fetch and dispatch pending messages without waiting for the next tick
+	// @Accept json
+	// @Produce json
+	// @Param batch_size query int false "One-off number of messages to fetch"
+	// @Success 202 {string} string "Message processing triggered"
+	// @Failure 400 {string} string "Invalid batch size"
+	// @Failure 409 {string} string "Message processing is not started"
+	// @Router /process_message/run_now [post]
+	r.HandleFunc("/process_message/run_now", api.RunMessageProcessingNow).Methods("POST")
+
+	// Get message processing state with its history
+	// @Summary Get message processing state
+	// @Description Get the cluster-wide message processing state and its change history
+	// @Accept json
+	// @Produce json
+	// @Param limit query int false "Limit of history entries to return"
+	// @Param offset query int false "Offset for pagination"
+	// @Param page query int false "Page number"
+	// @Success 200 {object} api.ProcessingStatusResponse "Current state and history"
+	// @Failure 500 {string} string "Internal server error"
+	// @Router /process_message/status [get]
+	r.HandleFunc("/process_message/status", api.GetMessageProcessingStatus).Methods("GET")
 
 	// List sent messages with pagination
 	// @Summary List sent messages
@@ -47,6 +73,52 @@ func Routers(api *api.Api) http.Handler {
 	// @Failure 500 {string} string "Internal server error"
 	// @Router /messages [get]
 	r.HandleFunc("/messages", api.ListSentMessages).Methods("GET")
+	r.HandleFunc("/messages", api.CreateMessage).Methods("POST")
+	r.HandleFunc("/messages/{id:[0-9]+}/cancel", api.CancelMessage).Methods("POST")
+
+	// Audit the status transitions of the messages (see the handlers for the Swagger annotations)
+	r.HandleFunc("/messages/{id:[0-9]+}/events", api.ListMessageEvents).Methods("GET")
+	r.HandleFunc("/message_events", api.ListMessageEventsBetween).Methods("GET")
+
+	// Manage recurring message schedules (see the handlers for the Swagger annotations)
+	r.HandleFunc("/schedules", api.ListSchedules).Methods("GET")
+	r.HandleFunc("/schedules", api.CreateSchedule).Methods("POST")
+	r.HandleFunc("/schedules/{id:[0-9]+}", api.GetSchedule).Methods("GET")
+	r.HandleFunc("/schedules/{id:[0-9]+}", api.UpdateSchedule).Methods("PUT")
+	r.HandleFunc("/schedules/{id:[0-9]+}", api.DeleteSchedule).Methods("DELETE")
+
+	// Manage the message templates (see the handlers for the Swagger annotations)
+	r.HandleFunc("/templates", api.ListTemplates).Methods("GET")
+	r.HandleFunc("/templates", api.CreateTemplate).Methods("POST")
+	r.HandleFunc("/templates/{id:[0-9]+}", api.GetTemplate).Methods("GET")
+	r.HandleFunc("/templates/{id:[0-9]+}", api.DeleteTemplate).Methods("DELETE")
+	r.HandleFunc("/templates/{id:[0-9]+}/versions", api.AddTemplateVersion).Methods("POST")
+
+	// Manage the campaigns and follow their progress (see the handlers for the Swagger annotations)
+	r.HandleFunc("/campaigns", api.ListCampaigns).Methods("GET")
+	r.HandleFunc("/campaigns", api.CreateCampaign).Methods("POST")
+	r.HandleFunc("/campaigns/{id:[0-9]+}", api.GetCampaign).Methods("GET")
+	r.HandleFunc("/campaigns/{id:[0-9]+}/recipients", api.AddCampaignRecipients).Methods("POST")
+	r.HandleFunc("/campaigns/{id:[0-9]+}/{action:start|pause|cancel}", api.UpdateCampaignStatus).Methods("POST")
+
+	// Manage the suppression list of opted out recipients (see the handlers for the Swagger annotations)
+	r.HandleFunc("/suppressions", api.ListSuppressions).Methods("GET")
+	r.HandleFunc("/suppressions", api.AddSuppression).Methods("POST")
+	r.HandleFunc("/suppressions/{recipient}", api.RemoveSuppression).Methods("DELETE")
+
+	// Receive the replies of recipients, handles the STOP and START keywords
+	r.HandleFunc("/webhooks/inbound", api.ReceiveInboundMessage).Methods("POST")
+
+	// List the circuit breakers of the notification senders
+	// @Summary List circuit breakers
+	// @Description Get the state of the circuit breakers around the notification senders and providers
+	// @Produce json
+	// @Success 200 {array} notification.BreakerStatus "List of circuit breakers"
+	// @Router /circuit_breakers [get]
+	r.HandleFunc("/circuit_breakers", api.ListCircuitBreakers).Methods("GET")
+
+	// Expose the runtime and application metrics in JSON
+	r.Handle("/debug/vars", expvar.Handler()).Methods("GET")
 
 	// Serve the Swagger UI at /swagger route
 	// r.PathPrefix("/swagger/").Handler(httpSwagger.Handler(
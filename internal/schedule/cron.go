@@ -0,0 +1,199 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCronExpression = errors.New("invalid cron expression")
+
+// maxCronLookahead bounds the search for the next fire time of expressions that never match, e.g. "0 0 30 2 *".
+const maxCronLookahead = 5 * 366 * 24 * time.Hour
+
+var cronMonthNames = map[string]int{
+	"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+	"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+}
+
+var cronWeekdayNames = map[string]int{
+	"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+}
+
+// cronField represents the bounds of a single field of a cron expression.
+type cronField struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	cronMinuteField  = cronField{name: "minute", min: 0, max: 59}
+	cronHourField    = cronField{name: "hour", min: 0, max: 23}
+	cronDayField     = cronField{name: "day of month", min: 1, max: 31}
+	cronMonthField   = cronField{name: "month", min: 1, max: 12, names: cronMonthNames}
+	cronWeekdayField = cronField{name: "day of week", min: 0, max: 7, names: cronWeekdayNames}
+)
+
+// CronExpression represents a parsed standard 5-field cron expression:
+// minute, hour, day of month, month and day of week.
+//
+// Each field accepts "*", single values, ranges ("1-5"), lists ("1,15") and steps ("*/15", "0-30/10").
+// Months and days of week accept three-letter names ("JAN", "MON"), Sunday is both 0 and 7.
+// Like the classic cron, when both day of month and day of week are restricted,
+// the expression fires on days matching either of them.
+type CronExpression struct {
+	minutes  uint64
+	hours    uint64
+	days     uint64
+	months   uint64
+	weekdays uint64
+
+	// daysRestricted and weekdaysRestricted report whether the field is not "*".
+	daysRestricted     bool
+	weekdaysRestricted bool
+}
+
+// ParseCron parses the given cron expression.
+func ParseCron(expr string) (*CronExpression, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: expected 5 fields, got %d", ErrInvalidCronExpression, len(fields))
+	}
+
+	var (
+		c   CronExpression
+		err error
+	)
+	if c.minutes, err = parseCronField(fields[0], cronMinuteField); err != nil {
+		return nil, err
+	}
+	if c.hours, err = parseCronField(fields[1], cronHourField); err != nil {
+		return nil, err
+	}
+	if c.days, err = parseCronField(fields[2], cronDayField); err != nil {
+		return nil, err
+	}
+	if c.months, err = parseCronField(fields[3], cronMonthField); err != nil {
+		return nil, err
+	}
+	if c.weekdays, err = parseCronField(fields[4], cronWeekdayField); err != nil {
+		return nil, err
+	}
+
+	// Sunday can be written both as 0 and 7.
+	if c.weekdays&(1<<7) != 0 {
+		c.weekdays |= 1 << 0
+	}
+	c.daysRestricted = fields[2] != "*"
+	c.weekdaysRestricted = fields[4] != "*"
+
+	return &c, nil
+}
+
+// Next returns the first fire time strictly after t, in t's location.
+// It returns the zero time if the expression never fires.
+func (c *CronExpression) Next(t time.Time) time.Time {
+	// Cron has minute resolution, start from the beginning of the next minute.
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxCronLookahead)
+
+	for t.Before(limit) {
+		if c.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.hours&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (c *CronExpression) matchesDay(t time.Time) bool {
+	dayMatches := c.days&(1<<uint(t.Day())) != 0
+	weekdayMatches := c.weekdays&(1<<uint(t.Weekday())) != 0
+
+	if c.daysRestricted && c.weekdaysRestricted {
+		return dayMatches || weekdayMatches
+	}
+
+	return dayMatches && weekdayMatches
+}
+
+// parseCronField parses a single field of a cron expression into a bit set of the matching values.
+func parseCronField(value string, field cronField) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(value, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			rangePart = part[:i]
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("%w: invalid step %q in %s field", ErrInvalidCronExpression, part[i+1:], field.name)
+			}
+		}
+
+		start, end := field.min, field.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if start, err = parseCronValue(bounds[0], field); err != nil {
+				return 0, err
+			}
+			if end, err = parseCronValue(bounds[1], field); err != nil {
+				return 0, err
+			}
+			if start > end {
+				return 0, fmt.Errorf("%w: invalid range %q in %s field", ErrInvalidCronExpression, rangePart, field.name)
+			}
+		default:
+			var err error
+			if start, err = parseCronValue(rangePart, field); err != nil {
+				return 0, err
+			}
+			// "5/15" means every 15 starting from 5.
+			if step == 1 {
+				end = start
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+func parseCronValue(value string, field cronField) (int, error) {
+	if v, ok := field.names[strings.ToUpper(value)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(value)
+	if err != nil || v < field.min || v > field.max {
+		return 0, fmt.Errorf("%w: invalid value %q in %s field", ErrInvalidCronExpression, value, field.name)
+	}
+
+	return v, nil
+}
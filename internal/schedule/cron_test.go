@@ -0,0 +1,116 @@
+package schedule
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseCron_Next(t *testing.T) {
+	// 2025-03-05 is a Wednesday.
+	from := time.Date(2025, 3, 5, 9, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		expr string
+		want time.Time
+	}{
+		{
+			name: "every minute",
+			expr: "* * * * *",
+			want: time.Date(2025, 3, 5, 9, 31, 0, 0, time.UTC),
+		},
+		{
+			name: "every Monday at 10:00",
+			expr: "0 10 * * MON",
+			want: time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "every 15 minutes",
+			expr: "*/15 * * * *",
+			want: time.Date(2025, 3, 5, 9, 45, 0, 0, time.UTC),
+		},
+		{
+			name: "weekdays range and hour list",
+			expr: "0 8,18 * * 1-5",
+			want: time.Date(2025, 3, 5, 18, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "first day of next month",
+			expr: "0 0 1 * *",
+			want: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "named month",
+			expr: "30 12 24 DEC *",
+			want: time.Date(2025, 12, 24, 12, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "sunday as 7",
+			expr: "0 9 * * 7",
+			want: time.Date(2025, 3, 9, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "day of month or day of week",
+			expr: "0 0 10 * FRI",
+			want: time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q) error = %v", tt.expr, err)
+			}
+
+			if got := expr.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCron_NextInLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+3", 3*60*60)
+	expr, err := ParseCron("0 10 * * *")
+	if err != nil {
+		t.Fatalf("ParseCron() error = %v", err)
+	}
+
+	got := expr.Next(time.Date(2025, 3, 5, 6, 0, 0, 0, time.UTC).In(loc))
+	want := time.Date(2025, 3, 5, 7, 0, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("Next() = %v, want %v", got, want)
+	}
+}
+
+func TestParseCron_NeverFires(t *testing.T) {
+	expr, err := ParseCron("0 0 30 FEB *")
+	if err != nil {
+		t.Fatalf("ParseCron() error = %v", err)
+	}
+
+	if got := expr.Next(time.Now()); !got.IsZero() {
+		t.Errorf("Next() = %v, want zero time", got)
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"* * * FOO *",
+	} {
+		if _, err := ParseCron(expr); !errors.Is(err, ErrInvalidCronExpression) {
+			t.Errorf("ParseCron(%q) error = %v, want %v", expr, err, ErrInvalidCronExpression)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mehmetalisavas/message-sender/internal/models"
+	"github.com/mehmetalisavas/message-sender/internal/pubsub"
+	"github.com/mehmetalisavas/message-sender/internal/service"
+	"github.com/mehmetalisavas/message-sender/pkg/messagetemplate"
+)
+
+// Make sure RecurringProducer implements Producer interface.
+var _ pubsub.Producer = (*RecurringProducer)(nil)
+
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// dueSchedulesLimit is the maximum number of schedules materialized on every tick.
+const dueSchedulesLimit = 100
+
+// RecurringProducer materializes pending messages from the recurring schedules at their fire times.
+// The messages are picked up by the MessageProducer like any other pending message.
+type RecurringProducer struct {
+	storageService service.Storage
+	// intervalInSec represents the interval in seconds to check the due schedules.
+	intervalInSec int
+}
+
+// NewRecurringProducer creates a new RecurringProducer instance.
+func NewRecurringProducer(storageService service.Storage, interval int) *RecurringProducer {
+	return &RecurringProducer{
+		storageService: storageService,
+		intervalInSec:  interval,
+	}
+}
+
+// Produce materializes the due schedules in every interval until the context is canceled.
+func (rp *RecurringProducer) Produce(ctx context.Context) error {
+	ticker := time.NewTicker(time.Duration(rp.intervalInSec) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := rp.materializeDueSchedules(ctx, time.Now()); err != nil {
+				log.Printf("failed to materialize due schedules: %v\n", err)
+			}
+		case <-ctx.Done():
+			log.Printf("recurring producer is stopped\n")
+			return nil
+		}
+	}
+}
+
+// materializeDueSchedules creates the pending messages of the schedules that are due at now.
+// Fire times missed while no instance was running are skipped, only the latest one is sent.
+// A schedule whose content can't be rendered stays due and is retried on the next tick.
+func (rp *RecurringProducer) materializeDueSchedules(ctx context.Context, now time.Time) error {
+	schedules, err := rp.storageService.GetDueSchedules(ctx, now, dueSchedulesLimit)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range schedules {
+		nextRunAt, err := NextRun(s, now)
+		if err != nil {
+			log.Printf("failed to compute next run of schedule id:%d: %v\n", s.ID, err)
+			continue
+		}
+
+		content, err := rp.content(ctx, s)
+		if err != nil {
+			log.Printf("failed to render schedule id:%d: %v\n", s.ID, err)
+			continue
+		}
+
+		created, err := rp.storageService.MaterializeSchedule(ctx, s, content, nextRunAt)
+		if err != nil {
+			log.Printf("failed to materialize schedule id:%d: %v\n", s.ID, err)
+			continue
+		}
+		if created {
+			log.Printf("schedule %d is materialized for %s\n", s.ID, s.NextRunAt.Format(time.RFC3339))
+		}
+	}
+
+	return nil
+}
+
+// content returns the content of the schedule, rendering its pinned template version if it uses one.
+func (rp *RecurringProducer) content(ctx context.Context, s models.Schedule) (string, error) {
+	if s.TemplateID == nil {
+		return s.Content, nil
+	}
+
+	t, err := rp.storageService.GetTemplate(ctx, *s.TemplateID, s.TemplateVersion)
+	if err != nil {
+		return "", err
+	}
+
+	return Render(*t, s)
+}
+
+// Render renders the template for the schedule's locale and variables.
+func Render(t models.Template, s models.Schedule) (string, error) {
+	body, ok := t.Body(s.Locale)
+	if !ok {
+		return "", fmt.Errorf("%w: template has no body for locale %q", ErrInvalidSchedule, s.Locale)
+	}
+
+	content, err := messagetemplate.Render(body, s.Variables)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	if utf8.RuneCountInString(content) > models.MaxContentLength {
+		return "", fmt.Errorf("%w: rendered content exceeds %d characters", ErrInvalidSchedule, models.MaxContentLength)
+	}
+
+	return content, nil
+}
+
+// NextRun returns the first fire time of the schedule strictly after t in UTC.
+// It returns nil if the schedule never fires again.
+func NextRun(s models.Schedule, t time.Time) (*time.Time, error) {
+	expr, err := ParseCron(s.CronExpression)
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidSchedule, s.Timezone)
+	}
+
+	next := expr.Next(t.In(loc))
+	if next.IsZero() {
+		return nil, nil
+	}
+	next = next.UTC()
+
+	return &next, nil
+}
+
+// Validate checks that the given schedule can be stored and materialized.
+func Validate(s models.Schedule) error {
+	if s.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidSchedule)
+	}
+	if s.Recipient == "" {
+		return fmt.Errorf("%w: recipient is required", ErrInvalidSchedule)
+	}
+	if (s.Content == "") == (s.TemplateID == nil) {
+		return fmt.Errorf("%w: either content or template_id is required", ErrInvalidSchedule)
+	}
+	if utf8.RuneCountInString(s.Content) > models.MaxContentLength {
+		return fmt.Errorf("%w: content exceeds %d characters", ErrInvalidSchedule, models.MaxContentLength)
+	}
+
+	_, err := NextRun(s, time.Now())
+	return err
+}
@@ -0,0 +1,91 @@
+package schedule
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mehmetalisavas/message-sender/internal/models"
+)
+
+func TestNextRun(t *testing.T) {
+	s := models.Schedule{
+		CronExpression: "0 10 * * MON",
+		Timezone:       "UTC",
+	}
+
+	next, err := NextRun(s, time.Date(2025, 3, 5, 9, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("NextRun() error = %v", err)
+	}
+	want := time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC)
+	if next == nil || !next.Equal(want) {
+		t.Errorf("NextRun() = %v, want %v", next, want)
+	}
+
+	s.CronExpression = "0 0 30 2 *"
+	next, err = NextRun(s, time.Now())
+	if err != nil {
+		t.Fatalf("NextRun() error = %v", err)
+	}
+	if next != nil {
+		t.Errorf("NextRun() = %v, want nil", next)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := models.Schedule{
+		Name:           "weekly reminder",
+		CronExpression: "0 10 * * MON",
+		Timezone:       "UTC",
+		Recipient:      "+905555555555",
+		Content:        "Don't forget the meeting",
+	}
+	if err := Validate(valid); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(s *models.Schedule)
+		wantErr error
+	}{
+		{"missing name", func(s *models.Schedule) { s.Name = "" }, ErrInvalidSchedule},
+		{"missing recipient", func(s *models.Schedule) { s.Recipient = "" }, ErrInvalidSchedule},
+		{"missing content", func(s *models.Schedule) { s.Content = "" }, ErrInvalidSchedule},
+		{"content and template", func(s *models.Schedule) { id := 1; s.TemplateID = &id }, ErrInvalidSchedule},
+		{"unknown timezone", func(s *models.Schedule) { s.Timezone = "Mars/Olympus" }, ErrInvalidSchedule},
+		{"invalid cron expression", func(s *models.Schedule) { s.CronExpression = "every monday" }, ErrInvalidCronExpression},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := valid
+			tt.modify(&s)
+			if err := Validate(s); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	tmpl := models.Template{
+		DefaultLocale: "en",
+		Bodies:        map[string]string{"en": "Hi {{.name}}, see you on Monday", "tr": "Merhaba {{.name}}, pazartesi görüşürüz"},
+	}
+	s := models.Schedule{Locale: "tr-TR", Variables: map[string]interface{}{"name": "Ali"}}
+
+	got, err := Render(tmpl, s)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := "Merhaba Ali, pazartesi görüşürüz"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	s.Variables = nil
+	if _, err := Render(tmpl, s); !errors.Is(err, ErrInvalidSchedule) {
+		t.Errorf("Render() error = %v, want %v", err, ErrInvalidSchedule)
+	}
+}
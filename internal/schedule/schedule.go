@@ -2,10 +2,7 @@ package schedule
 
 import (
 	"context"
-	"os"
-	"os/signal"
 	"sync"
-	"syscall"
 
 	"github.com/mehmetalisavas/message-sender/internal/pubsub"
 	"github.com/mehmetalisavas/message-sender/internal/service"
@@ -39,9 +36,9 @@ func (s *Schedule) AddConsumer(consumer pubsub.Consumer) {
 	s.consumers = append(s.consumers, consumer)
 }
 
+// Start runs the producers and the consumers with workerCount workers each until ctx is done,
+// then waits for all of them to return.
 func (s *Schedule) Start(ctx context.Context, workerCount int) {
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel() // this is just a fallback in case the function exits earlier due to an error.
 
 	for _, producer := range s.producers {
 		s.wg.Add(1)
@@ -59,11 +56,7 @@ func (s *Schedule) Start(ctx context.Context, workerCount int) {
 		}(consumer)
 	}
 
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
-	<-stop
-
-	cancel()    // cancel the context before doing cleanup
+	<-ctx.Done()
 	s.wg.Wait() // wait for all producers and consumers to finish
 }
 
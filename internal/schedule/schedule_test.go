@@ -11,6 +11,7 @@ import (
 	"github.com/mehmetalisavas/message-sender/internal/db/mysql"
 	"github.com/mehmetalisavas/message-sender/internal/db/redis"
 	"github.com/mehmetalisavas/message-sender/internal/models"
+	"github.com/mehmetalisavas/message-sender/internal/processing"
 	"github.com/mehmetalisavas/message-sender/internal/pubsub"
 	"github.com/mehmetalisavas/message-sender/pkg/services/notification"
 	"github.com/sethvargo/go-envconfig"
@@ -73,11 +74,12 @@ func TestScheduler(t *testing.T) {
 		t.Fatalf("Failed to insert message: %v", err)
 	}
 
-	notificationService := &MockNotificationService{}
+	notificationSenders := notification.NewRegistry()
+	notificationSenders.Register(notification.ChannelSMS, &MockNotificationService{})
 	scheduler := NewScheduler(store)
-	messageProducer := pubsub.NewMessageProducer(&c, store, scheduler.MessageBus(), 1)
+	messageProducer := pubsub.NewMessageProducer(processing.NewController(models.ProcessingStateStarted), store, scheduler.MessageBus(), 1, time.Minute)
 	scheduler.AddProducer(messageProducer)
-	messageConsumer := pubsub.NewMessageConsumer(store, scheduler.MessageBus(), notificationService, cacheService)
+	messageConsumer := pubsub.NewMessageConsumer(store, scheduler.MessageBus(), notificationSenders, cacheService, c.DefaultCountryCode, 0, 0, time.Minute)
 	scheduler.AddConsumer(messageConsumer)
 
 	go scheduler.Start(ctx, 2) // start with 2 workers
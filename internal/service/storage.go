@@ -13,17 +13,143 @@ var _ Storage = (*mysql.SqlStore)(nil)
 
 // Storage represents the storage service.
 type Storage interface {
+	// CreateMessage creates a new pending message, recording its creation as made by the given actor.
+	CreateMessage(ctx context.Context, message models.Message, actor string) (*models.Message, error)
+
 	// ListSentMessages returns all sent messages according to given options.
 	ListSentMessages(ctx context.Context, opts models.ListOptions) ([]models.Message, error)
 
-	// GetPendingMessages returns pending messages from the storage in a given limit.
-	GetPendingMessages(ctx context.Context, limit int) ([]models.Message, error)
+	// GetPendingMessages marks up to limit pending messages as processing and leases them for visibilityTimeout.
+	// Processing messages whose lease expired are returned again with a new lease.
+	GetPendingMessages(ctx context.Context, limit int, visibilityTimeout time.Duration) ([]models.Message, error)
+
+	// ExtendMessageLease extends the lease of the processing message with the given id by visibilityTimeout.
+	// It returns models.ErrLeaseLost if the message isn't leased with leaseToken anymore.
+	ExtendMessageLease(ctx context.Context, id int, leaseToken string, visibilityTimeout time.Duration) error
+
+	// ReleaseMessage applies the update to the processing message leased with its token and ends the lease.
+	// It returns a *models.StatusConflictError if the message isn't processing anymore, and
+	// models.ErrLeaseLost if it is processed by another worker.
+	ReleaseMessage(ctx context.Context, update models.MessageStatusUpdate) error
+
+	// UpdateMessageStatus moves the message with the given id from status from to status to, the transition
+	// is recorded with the actor and reason. It serves single transitions outside of a lease, like cancelling
+	// a pending message through the API, leased messages are updated with UpdateMessageStatuses.
+	// It returns models.ErrInvalidMessageTransition if the transition isn't allowed, a *models.StatusConflictError
+	// with the actual status if the message isn't in status from anymore, and models.ErrNotFound if it doesn't exist.
+	UpdateMessageStatus(ctx context.Context, id int, from, to models.MessageStatus, actor, reason string) error
+
+	// UpdateMessageStatuses applies the updates to the processing messages leased with their tokens in a
+	// single statement and records them as message events. The updates that can't be applied are returned
+	// keyed by message id with the reason: a *models.StatusConflictError, models.ErrLeaseLost or models.ErrNotFound.
+	UpdateMessageStatuses(ctx context.Context, updates []models.MessageStatusUpdate) (map[int]error, error)
+
+	// ListMessageEvents returns the status transitions of the message with the given id, oldest first.
+	ListMessageEvents(ctx context.Context, messageID int) ([]models.MessageEvent, error)
+
+	// ListMessageEventsBetween returns the status transitions of all messages made in [from, to),
+	// oldest first, according to given options.
+	ListMessageEventsBetween(ctx context.Context, from, to time.Time, opts models.ListOptions) ([]models.MessageEvent, error)
+
+	// GetProcessingState returns the latest cluster-wide message processing state.
+	GetProcessingState(ctx context.Context) (*models.ProcessingStateChange, error)
+
+	// SetProcessingState persists a new message processing state changed by the given actor.
+	SetProcessingState(ctx context.Context, state models.ProcessingState, changedBy string) (*models.ProcessingStateChange, error)
+
+	// ListProcessingStateChanges returns the history of processing state changes according to given options.
+	ListProcessingStateChanges(ctx context.Context, opts models.ListOptions) ([]models.ProcessingStateChange, error)
+
+	// CreateCampaign creates a new draft campaign.
+	CreateCampaign(ctx context.Context, campaign models.Campaign) (*models.Campaign, error)
+
+	// GetCampaign returns the campaign with the given id.
+	GetCampaign(ctx context.Context, id int) (*models.Campaign, error)
+
+	// ListCampaigns returns the campaigns according to given options.
+	ListCampaigns(ctx context.Context, opts models.ListOptions) ([]models.Campaign, error)
+
+	// AddCampaignMessages creates the messages as pending messages of the campaign with the given id,
+	// recording their creation as made by the given actor.
+	AddCampaignMessages(ctx context.Context, campaignID int, messages []models.Message, actor string) error
+
+	// SetCampaignStatus moves the campaign with the given id to the given status if the transition is allowed.
+	// The messages cancelled along with the campaign are recorded as changed by the given actor.
+	SetCampaignStatus(ctx context.Context, id int, status models.CampaignStatus, changedBy string) (*models.Campaign, error)
+
+	// GetCampaignStats returns the number of messages of the campaign with the given id per status.
+	GetCampaignStats(ctx context.Context, id int) (map[models.MessageStatus]int, error)
+
+	// AddSuppression adds the recipient to the suppression list, or updates its existing entry.
+	// Entries added through the API keep their source, so that they can't be removed by keyword.
+	AddSuppression(ctx context.Context, suppression models.Suppression) (*models.Suppression, error)
+
+	// RemoveSuppression removes the recipient from the suppression list.
+	RemoveSuppression(ctx context.Context, recipient string) error
 
-	// UpdateMessageStatus updates the status of the message with the given id.
-	UpdateMessageStatus(ctx context.Context, id int, status models.MessageStatus) error
+	// RemoveSuppressionWithSource removes the recipient from the suppression list if it was added from the given source.
+	RemoveSuppressionWithSource(ctx context.Context, recipient string, source models.SuppressionSource) error
+
+	// ListSuppressions returns the suppression list according to given options.
+	ListSuppressions(ctx context.Context, opts models.ListOptions) ([]models.Suppression, error)
+
+	// IsSuppressed reports whether the recipient is on the suppression list.
+	IsSuppressed(ctx context.Context, recipient string) (bool, error)
+
+	// CreateTemplate creates a new template with its bodies as the first version.
+	CreateTemplate(ctx context.Context, template models.Template) (*models.Template, error)
+
+	// GetTemplate returns the template with the given id and the bodies of the given version, zero meaning the latest.
+	GetTemplate(ctx context.Context, id, version int) (*models.Template, error)
+
+	// ListTemplates returns the templates according to given options, without their bodies.
+	ListTemplates(ctx context.Context, opts models.ListOptions) ([]models.Template, error)
+
+	// AddTemplateVersion stores the bodies as the next version of the template with the given id.
+	AddTemplateVersion(ctx context.Context, id int, bodies map[string]string) (*models.Template, error)
+
+	// DeleteTemplate deletes the template with the given id and all of its versions.
+	// It returns models.ErrInUse while campaigns or schedules use the template.
+	DeleteTemplate(ctx context.Context, id int) error
+
+	// CreateSchedule creates a new recurring schedule.
+	CreateSchedule(ctx context.Context, schedule models.Schedule) (*models.Schedule, error)
+
+	// GetSchedule returns the schedule with the given id.
+	GetSchedule(ctx context.Context, id int) (*models.Schedule, error)
+
+	// ListSchedules returns the schedules according to given options.
+	ListSchedules(ctx context.Context, opts models.ListOptions) ([]models.Schedule, error)
+
+	// UpdateSchedule updates the given schedule.
+	UpdateSchedule(ctx context.Context, schedule models.Schedule) (*models.Schedule, error)
+
+	// DeleteSchedule deletes the schedule with the given id.
+	DeleteSchedule(ctx context.Context, id int) error
+
+	// GetDueSchedules returns the enabled schedules that are due at the given time in a given limit.
+	GetDueSchedules(ctx context.Context, now time.Time, limit int) ([]models.Schedule, error)
+
+	// MaterializeSchedule creates the pending message of the schedule's current fire time with the given content
+	// exactly once and moves the schedule to the next run time. It reports whether the message is created by this call.
+	MaterializeSchedule(ctx context.Context, schedule models.Schedule, content string, nextRunAt *time.Time) (bool, error)
 }
 
 // CacheStore represents the cache store service.
 type CacheStore interface {
+	// CacheMessage caches the provider's message ID with its send time.
 	CacheMessage(ctx context.Context, messageId string, sendTime time.Time) error
+
+	// ReserveDedupeKey records the message as the first one with the recipient and content within window.
+	// It returns the ID of the earlier message when there is one, 0 otherwise.
+	ReserveDedupeKey(ctx context.Context, recipient, content string, messageID int, window time.Duration) (int, error)
+
+	// ReleaseDedupeKey releases the reservation of the recipient and content if the message still holds it.
+	ReleaseDedupeKey(ctx context.Context, recipient, content string, messageID int) error
+
+	// GetDeliveryMarker returns the delivery marker of the message with the given id, nil if there is none.
+	GetDeliveryMarker(ctx context.Context, messageID int) (*models.DeliveryMarker, error)
+
+	// SetDeliveryMarker records the delivery marker of the message with the given id for ttl.
+	SetDeliveryMarker(ctx context.Context, messageID int, marker models.DeliveryMarker, ttl time.Duration) error
 }
@@ -0,0 +1,9 @@
+CREATE TABLE processing_states (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    state ENUM('started', 'stopped') NOT NULL,
+    changed_by VARCHAR(255) NOT NULL,
+    changed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- message processing is enabled by default
+INSERT INTO processing_states (state, changed_by) VALUES ('started', 'system');
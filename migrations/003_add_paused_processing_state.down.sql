@@ -0,0 +1,2 @@
+UPDATE processing_states SET state = 'stopped' WHERE state = 'paused';
+ALTER TABLE processing_states MODIFY state ENUM('started', 'stopped') NOT NULL;
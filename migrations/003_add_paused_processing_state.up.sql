@@ -0,0 +1 @@
+ALTER TABLE processing_states MODIFY state ENUM('started', 'stopped', 'paused') NOT NULL;
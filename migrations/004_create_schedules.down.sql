@@ -0,0 +1,7 @@
+ALTER TABLE messages
+    DROP FOREIGN KEY fk_messages_schedule,
+    DROP INDEX uq_messages_schedule_fire,
+    DROP COLUMN schedule_id,
+    DROP COLUMN scheduled_for;
+
+DROP TABLE IF EXISTS schedules;
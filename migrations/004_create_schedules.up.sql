@@ -0,0 +1,21 @@
+CREATE TABLE schedules (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    cron_expression VARCHAR(255) NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    recipient VARCHAR(20) NOT NULL,
+    content TEXT NOT NULL CHECK (CHAR_LENGTH(content) <= 255),
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    next_run_at DATETIME NULL,
+    last_run_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_schedules_due (enabled, next_run_at)
+);
+
+-- a schedule materializes a single message per fire time
+ALTER TABLE messages
+    ADD COLUMN schedule_id INT NULL,
+    ADD COLUMN scheduled_for DATETIME NULL,
+    ADD CONSTRAINT fk_messages_schedule FOREIGN KEY (schedule_id) REFERENCES schedules (id) ON DELETE SET NULL,
+    ADD UNIQUE KEY uq_messages_schedule_fire (schedule_id, scheduled_for);
@@ -0,0 +1,3 @@
+ALTER TABLE messages
+    DROP COLUMN channel,
+    MODIFY recipient VARCHAR(20) NOT NULL;
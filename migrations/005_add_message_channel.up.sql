@@ -0,0 +1,4 @@
+-- email addresses and push tokens don't fit into a phone number column
+ALTER TABLE messages
+    MODIFY recipient VARCHAR(255) NOT NULL,
+    ADD COLUMN channel ENUM('sms', 'email', 'push', 'chat') NOT NULL DEFAULT 'sms' AFTER recipient;
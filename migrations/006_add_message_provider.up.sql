@@ -0,0 +1 @@
+ALTER TABLE messages ADD COLUMN provider VARCHAR(64) NULL AFTER channel;
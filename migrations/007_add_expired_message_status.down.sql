@@ -0,0 +1,2 @@
+UPDATE messages SET status = 'failed' WHERE status = 'expired';
+ALTER TABLE messages MODIFY status ENUM('pending', 'processing', 'sent', 'failed') DEFAULT 'pending';
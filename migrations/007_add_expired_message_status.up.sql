@@ -0,0 +1 @@
+ALTER TABLE messages MODIFY status ENUM('pending', 'processing', 'sent', 'failed', 'expired') DEFAULT 'pending';
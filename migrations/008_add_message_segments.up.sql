@@ -0,0 +1 @@
+ALTER TABLE messages ADD COLUMN segments INT UNSIGNED NULL AFTER provider;
@@ -0,0 +1,4 @@
+UPDATE messages SET status = 'failed' WHERE status = 'suppressed';
+ALTER TABLE messages MODIFY status ENUM('pending', 'processing', 'sent', 'failed', 'expired') DEFAULT 'pending';
+
+DROP TABLE IF EXISTS suppressions;
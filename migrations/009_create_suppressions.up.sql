@@ -0,0 +1,10 @@
+CREATE TABLE suppressions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    recipient VARCHAR(255) NOT NULL,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    source ENUM('api', 'keyword') NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_suppressions_recipient (recipient)
+);
+
+ALTER TABLE messages MODIFY status ENUM('pending', 'processing', 'sent', 'failed', 'expired', 'suppressed') DEFAULT 'pending';
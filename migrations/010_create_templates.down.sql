@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS template_bodies;
+DROP TABLE IF EXISTS templates;
@@ -0,0 +1,20 @@
+CREATE TABLE templates (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    default_locale VARCHAR(16) NOT NULL,
+    latest_version INT NOT NULL DEFAULT 1,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_templates_name (name)
+);
+
+-- every version of a template has one body per locale
+CREATE TABLE template_bodies (
+    template_id INT NOT NULL,
+    version INT NOT NULL,
+    locale VARCHAR(16) NOT NULL,
+    body TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (template_id, version, locale),
+    CONSTRAINT fk_template_bodies_template FOREIGN KEY (template_id) REFERENCES templates (id) ON DELETE CASCADE
+);
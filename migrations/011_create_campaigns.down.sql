@@ -0,0 +1,8 @@
+UPDATE messages SET status = 'failed' WHERE status = 'cancelled';
+ALTER TABLE messages
+    DROP FOREIGN KEY fk_messages_campaign,
+    DROP INDEX idx_messages_campaign_status,
+    DROP COLUMN campaign_id,
+    MODIFY status ENUM('pending', 'processing', 'sent', 'failed', 'expired', 'suppressed') DEFAULT 'pending';
+
+DROP TABLE IF EXISTS campaigns;
@@ -0,0 +1,19 @@
+CREATE TABLE campaigns (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    channel ENUM('sms', 'email', 'push', 'chat') NOT NULL DEFAULT 'sms',
+    content TEXT NULL,
+    template_id INT NULL,
+    template_version INT NULL,
+    locale VARCHAR(16) NULL,
+    status ENUM('draft', 'running', 'paused', 'cancelled') NOT NULL DEFAULT 'draft',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_campaigns_template FOREIGN KEY (template_id) REFERENCES templates (id) ON DELETE SET NULL
+);
+
+ALTER TABLE messages
+    MODIFY status ENUM('pending', 'processing', 'sent', 'failed', 'expired', 'suppressed', 'cancelled') DEFAULT 'pending',
+    ADD COLUMN campaign_id INT NULL,
+    ADD CONSTRAINT fk_messages_campaign FOREIGN KEY (campaign_id) REFERENCES campaigns (id) ON DELETE SET NULL,
+    ADD INDEX idx_messages_campaign_status (campaign_id, status);
@@ -0,0 +1,2 @@
+UPDATE messages SET status = 'failed' WHERE status = 'deduplicated';
+ALTER TABLE messages MODIFY status ENUM('pending', 'processing', 'sent', 'failed', 'expired', 'suppressed', 'cancelled') DEFAULT 'pending';
@@ -0,0 +1 @@
+ALTER TABLE messages MODIFY status ENUM('pending', 'processing', 'sent', 'failed', 'expired', 'suppressed', 'cancelled', 'deduplicated') DEFAULT 'pending';
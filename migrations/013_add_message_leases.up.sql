@@ -0,0 +1,7 @@
+ALTER TABLE messages
+    ADD COLUMN lease_token CHAR(36) NULL,
+    ADD COLUMN lease_expires_at DATETIME NULL,
+    ADD INDEX idx_messages_status_lease (status, lease_expires_at);
+
+-- Messages being processed keep the reclaim window they had before leases.
+UPDATE messages SET lease_expires_at = updated_at + INTERVAL 5 MINUTE WHERE status = 'processing';
@@ -0,0 +1,11 @@
+CREATE TABLE message_events (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    message_id INT NOT NULL,
+    from_status VARCHAR(32) NOT NULL,
+    to_status VARCHAR(32) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    reason VARCHAR(255) NULL,
+    created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    INDEX idx_message_events_message (message_id, id),
+    INDEX idx_message_events_created_at (created_at)
+);
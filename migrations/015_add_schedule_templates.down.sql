@@ -0,0 +1,6 @@
+ALTER TABLE schedules
+    DROP FOREIGN KEY fk_schedules_template,
+    DROP COLUMN template_id,
+    DROP COLUMN template_version,
+    DROP COLUMN locale,
+    DROP COLUMN variables;
@@ -0,0 +1,7 @@
+-- template schedules render their template at every fire time, content is left empty
+ALTER TABLE schedules
+    ADD COLUMN template_id INT NULL,
+    ADD COLUMN template_version INT NULL,
+    ADD COLUMN locale VARCHAR(16) NULL,
+    ADD COLUMN variables JSON NULL,
+    ADD CONSTRAINT fk_schedules_template FOREIGN KEY (template_id) REFERENCES templates (id);
@@ -0,0 +1,3 @@
+ALTER TABLE campaigns
+    DROP FOREIGN KEY fk_campaigns_template,
+    ADD CONSTRAINT fk_campaigns_template FOREIGN KEY (template_id) REFERENCES templates (id) ON DELETE SET NULL;
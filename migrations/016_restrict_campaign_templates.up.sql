@@ -0,0 +1,4 @@
+-- a campaign keeps its template, deleting a template used by a campaign is rejected like for schedules
+ALTER TABLE campaigns
+    DROP FOREIGN KEY fk_campaigns_template,
+    ADD CONSTRAINT fk_campaigns_template FOREIGN KEY (template_id) REFERENCES templates (id);
@@ -0,0 +1,44 @@
+// Package messagetemplate renders message bodies written with text/template
+// placeholders, e.g. "Hello {{.name}}, your code is {{.code}}".
+package messagetemplate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// ErrInvalidTemplate is returned for bodies that can't be parsed or rendered.
+var ErrInvalidTemplate = errors.New("invalid template")
+
+// Parse parses the body, every variable used by the body must be given when rendering it.
+func Parse(body string) (*template.Template, error) {
+	if strings.TrimSpace(body) == "" {
+		return nil, fmt.Errorf("%w: body is empty", ErrInvalidTemplate)
+	}
+
+	tmpl, err := template.New("message").Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	return tmpl, nil
+}
+
+// Render renders the body with the given variables.
+func Render(body string, variables map[string]interface{}) (string, error) {
+	tmpl, err := Parse(body)
+	if err != nil {
+		return "", err
+	}
+
+	if variables == nil {
+		variables = map[string]interface{}{}
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, variables); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,36 @@
+package messagetemplate
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	got, err := Render("Hello {{.name}}, your code is {{.code}}", map[string]interface{}{"name": "Ali", "code": 1234})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := "Hello Ali, your code is 1234"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestRender_Invalid(t *testing.T) {
+	tests := map[string]struct {
+		body      string
+		variables map[string]interface{}
+	}{
+		"empty body":       {body: " "},
+		"parse error":      {body: "Hello {{.name"},
+		"missing variable": {body: "Hello {{.name}}", variables: map[string]interface{}{"code": 1}},
+		"nil variables":    {body: "Hello {{.name}}"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Render(tt.body, tt.variables); !errors.Is(err, ErrInvalidTemplate) {
+				t.Errorf("Render() error = %v, want %v", err, ErrInvalidTemplate)
+			}
+		})
+	}
+}
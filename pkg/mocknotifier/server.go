@@ -0,0 +1,256 @@
+// Package mocknotifier provides a fake notification provider for local
+// development and tests. It accepts messages the way the notification service
+// does, answers with message IDs and records what it received. Latency, errors,
+// rate limiting and delivery receipts can be simulated through Config. Requests
+// repeating the Idempotency-Key of an accepted message get the same message ID
+// and are not recorded again.
+package mocknotifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Config controls the behaviour of the Server.
+type Config struct {
+	// Latency delays every response.
+	Latency time.Duration
+	// ErrorRate is the share of requests, between 0 and 1, answered with 500.
+	ErrorRate float64
+	// RateLimitRate is the share of requests, between 0 and 1, answered with 429.
+	RateLimitRate float64
+	// RetryAfter is sent in the Retry-After header of 429 responses, in whole seconds.
+	RetryAfter time.Duration
+	// CallbackURL receives a DeliveryReceipt for every accepted message when set.
+	CallbackURL string
+	// CallbackDelay delays the delivery receipts.
+	CallbackDelay time.Duration
+}
+
+// Request is a message sent to the Server.
+type Request struct {
+	To      string `json:"to"`
+	Content string `json:"content"`
+}
+
+// Response is the answer to an accepted message, same as the notification service.
+type Response struct {
+	Message   string `json:"message"`
+	MessageID string `json:"messageId"`
+}
+
+// DeliveryReceipt is posted to the callback URL once a message is delivered.
+type DeliveryReceipt struct {
+	MessageID   string    `json:"messageId"`
+	To          string    `json:"to"`
+	Status      string    `json:"status"`
+	DeliveredAt time.Time `json:"deliveredAt"`
+}
+
+// ReceivedMessage is a message received by the Server, including the rejected ones.
+type ReceivedMessage struct {
+	To      string `json:"to"`
+	Content string `json:"content"`
+	// MessageID is empty unless the message was accepted.
+	MessageID  string      `json:"messageId,omitempty"`
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"-"`
+	ReceivedAt time.Time   `json:"receivedAt"`
+}
+
+// Server is an http.Handler mimicking a notification provider.
+// POST requests send a message, GET requests list the received messages.
+type Server struct {
+	config Config
+	client *http.Client
+
+	mu       sync.Mutex
+	rand     *rand.Rand
+	messages []ReceivedMessage
+	// accepted maps the Idempotency-Key of the accepted messages to their message ID.
+	accepted map[string]string
+}
+
+// New creates a new Server with the given config.
+func New(config Config) *Server {
+	return &Server{
+		config:   config,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		accepted: make(map[string]string),
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.Messages())
+	case http.MethodPost:
+		s.send(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) send(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeRequest(r)
+	if err != nil || req.To == "" || req.Content == "" {
+		s.record(req, "", http.StatusBadRequest, r.Header)
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "to and content are required"})
+		return
+	}
+
+	// A repeated request is answered like the first one without sending the message again.
+	key := r.Header.Get("Idempotency-Key")
+	if messageID, ok := s.acceptedMessageID(key); ok {
+		writeJSON(w, http.StatusAccepted, Response{Message: "Accepted", MessageID: messageID})
+		return
+	}
+
+	if s.config.Latency > 0 {
+		select {
+		case <-time.After(s.config.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	switch outcome := s.roll(); {
+	case outcome < s.config.RateLimitRate:
+		s.record(req, "", http.StatusTooManyRequests, r.Header)
+		if s.config.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(s.config.RetryAfter/time.Second)))
+		}
+		writeJSON(w, http.StatusTooManyRequests, map[string]string{"message": "Too Many Requests"})
+		return
+	case outcome < s.config.RateLimitRate+s.config.ErrorRate:
+		s.record(req, "", http.StatusInternalServerError, r.Header)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"message": "Internal Server Error"})
+		return
+	}
+
+	messageID := uuid.NewString()
+	s.record(req, messageID, http.StatusAccepted, r.Header)
+	if key != "" {
+		s.mu.Lock()
+		s.accepted[key] = messageID
+		s.mu.Unlock()
+	}
+	writeJSON(w, http.StatusAccepted, Response{Message: "Accepted", MessageID: messageID})
+
+	if s.config.CallbackURL != "" {
+		go s.sendReceipt(DeliveryReceipt{MessageID: messageID, To: req.To, Status: "delivered"})
+	}
+}
+
+// decodeRequest reads JSON requests as well as the form encoded requests of SMS gateways.
+func decodeRequest(r *http.Request) (Request, error) {
+	var req Request
+	if r.Header.Get("Content-Type") == "application/x-www-form-urlencoded" {
+		if err := r.ParseForm(); err != nil {
+			return req, err
+		}
+		req.To = r.PostForm.Get("to")
+		req.Content = r.PostForm.Get("text")
+		return req, nil
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	return req, err
+}
+
+func (s *Server) acceptedMessageID(key string) (string, bool) {
+	if key == "" {
+		return "", false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	messageID, ok := s.accepted[key]
+	return messageID, ok
+}
+
+func (s *Server) roll() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rand.Float64()
+}
+
+func (s *Server) record(req Request, messageID string, statusCode int, header http.Header) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = append(s.messages, ReceivedMessage{
+		To:         req.To,
+		Content:    req.Content,
+		MessageID:  messageID,
+		StatusCode: statusCode,
+		Header:     header.Clone(),
+		ReceivedAt: time.Now(),
+	})
+}
+
+func (s *Server) sendReceipt(receipt DeliveryReceipt) {
+	time.Sleep(s.config.CallbackDelay)
+	receipt.DeliveredAt = time.Now()
+
+	body, err := json.Marshal(receipt)
+	if err != nil {
+		log.Printf("failed to encode delivery receipt: %v\n", err)
+		return
+	}
+
+	resp, err := s.client.Post(s.config.CallbackURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("failed to send delivery receipt of %s: %v\n", receipt.MessageID, err)
+		return
+	}
+	resp.Body.Close()
+}
+
+// Messages returns the received messages in the order they were received.
+func (s *Server) Messages() []ReceivedMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	messages := make([]ReceivedMessage, len(s.messages))
+	copy(messages, s.messages)
+	return messages
+}
+
+// Accepted returns the accepted messages in the order they were received.
+func (s *Server) Accepted() []ReceivedMessage {
+	var accepted []ReceivedMessage
+	for _, m := range s.Messages() {
+		if m.MessageID != "" {
+			accepted = append(accepted, m)
+		}
+	}
+	return accepted
+}
+
+// Reset forgets the received messages.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = nil
+	s.accepted = make(map[string]string)
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
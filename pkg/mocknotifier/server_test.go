@@ -0,0 +1,140 @@
+package mocknotifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mehmetalisavas/message-sender/pkg/retry"
+	"github.com/mehmetalisavas/message-sender/pkg/services/notification"
+)
+
+func TestServer_AcceptsMessages(t *testing.T) {
+	server, url := NewTestServer(t, Config{})
+
+	ns := notification.NewNotificationService(url, time.Second)
+	resp, err := ns.Send(context.Background(), "+905555555555", "hello")
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	accepted := server.Accepted()
+	if len(accepted) != 1 {
+		t.Fatalf("expected 1 accepted message, got %d", len(accepted))
+	}
+	if accepted[0].To != "+905555555555" || accepted[0].Content != "hello" {
+		t.Errorf("unexpected message %+v", accepted[0])
+	}
+	if accepted[0].MessageID != resp.MessageID {
+		t.Errorf("expected message ID %s, got %s", accepted[0].MessageID, resp.MessageID)
+	}
+}
+
+func TestServer_AcceptsFormRequests(t *testing.T) {
+	server, url := NewTestServer(t, Config{})
+
+	sender := notification.NewSMSGatewaySender(url, "Insider", time.Second)
+	if _, err := sender.Send(context.Background(), "+905555555555", "hello"); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if accepted := server.Accepted(); len(accepted) != 1 || accepted[0].Content != "hello" {
+		t.Errorf("unexpected messages %+v", accepted)
+	}
+}
+
+func TestServer_IdempotencyKey(t *testing.T) {
+	server, url := NewTestServer(t, Config{})
+
+	ns := notification.NewNotificationService(url, time.Second)
+	ctx := notification.WithIdempotencyKey(context.Background(), "message-1")
+	first, err := ns.Send(ctx, "+905555555555", "hello")
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	second, err := ns.Send(ctx, "+905555555555", "hello")
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if first.MessageID != second.MessageID {
+		t.Errorf("expected the same message ID, got %s and %s", first.MessageID, second.MessageID)
+	}
+	accepted := server.Accepted()
+	if len(accepted) != 1 {
+		t.Fatalf("expected 1 accepted message, got %d", len(accepted))
+	}
+	if got := accepted[0].Header.Get(notification.IdempotencyKeyHeader); got != "message-1" {
+		t.Errorf("expected idempotency key message-1, got %q", got)
+	}
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	_, url := NewTestServer(t, Config{RateLimitRate: 1, RetryAfter: 3 * time.Second})
+
+	resp, err := http.Post(url, "application/json", strings.NewReader(`{"to": "+905555555555", "content": "hello"}`))
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected status code 429, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Retry-After"); got != "3" {
+		t.Errorf("expected Retry-After 3, got %q", got)
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	server, url := NewTestServer(t, Config{ErrorRate: 1})
+
+	client := &http.Client{Timeout: time.Second}
+	_, err := retry.Retry(context.Background(), func() (*http.Response, error) {
+		return client.Post(url, "application/json", strings.NewReader(`{"to": "+905555555555", "content": "hello"}`))
+	}, retry.Config{MaxRetries: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, BackoffFactor: 2})
+
+	var statusErr *retry.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected status code 500 error, got %v", err)
+	}
+	if received := server.Messages(); len(received) != 3 {
+		t.Errorf("expected 3 received messages, got %d", len(received))
+	}
+	if accepted := server.Accepted(); len(accepted) != 0 {
+		t.Errorf("expected no accepted message, got %d", len(accepted))
+	}
+}
+
+func TestServer_DeliveryReceipts(t *testing.T) {
+	receipts := make(chan DeliveryReceipt, 1)
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var receipt DeliveryReceipt
+		if err := json.NewDecoder(r.Body).Decode(&receipt); err != nil {
+			t.Errorf("failed to decode receipt: %v", err)
+		}
+		receipts <- receipt
+	}))
+	defer callback.Close()
+
+	_, url := NewTestServer(t, Config{CallbackURL: callback.URL})
+
+	ns := notification.NewNotificationService(url, time.Second)
+	resp, err := ns.Send(context.Background(), "+905555555555", "hello")
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	select {
+	case receipt := <-receipts:
+		if receipt.MessageID != resp.MessageID || receipt.Status != "delivered" {
+			t.Errorf("unexpected receipt %+v", receipt)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a delivery receipt")
+	}
+}
@@ -0,0 +1,18 @@
+package mocknotifier
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// NewTestServer starts a Server with the given config on a local test server,
+// which is closed when the test finishes. It returns the server and its URL.
+func NewTestServer(tb testing.TB, config Config) (*Server, string) {
+	tb.Helper()
+
+	server := New(config)
+	ts := httptest.NewServer(server)
+	tb.Cleanup(ts.Close)
+
+	return server, ts.URL
+}
@@ -0,0 +1,74 @@
+// Package phonenumber validates phone numbers and normalizes them to E.164,
+// e.g. "+905551234567", so that the same number is always stored the same way.
+package phonenumber
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// minDigits is the minimum number of digits, country code included, of a valid number.
+	minDigits = 8
+	// maxDigits is the maximum number of digits allowed by E.164, country code included.
+	maxDigits = 15
+)
+
+// ErrInvalidPhoneNumber is returned for numbers that can't be normalized to E.164.
+var ErrInvalidPhoneNumber = errors.New("invalid phone number")
+
+// separators are the characters commonly used to format phone numbers, they are ignored.
+var separators = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "", "/", "")
+
+// Normalize parses number and returns it in E.164 format.
+//
+// International numbers start with "+" or "00". Other numbers are considered
+// national: their trunk prefix "0" is dropped and defaultCountryCode, e.g. "90"
+// or "+90", is prepended. National numbers are rejected when no default
+// country code is given.
+func Normalize(number, defaultCountryCode string) (string, error) {
+	digits := separators.Replace(strings.TrimSpace(number))
+
+	switch {
+	case digits == "":
+		return "", fmt.Errorf("%w: number is empty", ErrInvalidPhoneNumber)
+	case strings.HasPrefix(digits, "+"):
+		digits = digits[1:]
+	case strings.HasPrefix(digits, "00"):
+		digits = digits[2:]
+	default:
+		countryCode := strings.TrimPrefix(defaultCountryCode, "+")
+		if countryCode == "" {
+			return "", fmt.Errorf("%w %q: country code is missing", ErrInvalidPhoneNumber, number)
+		}
+		if !isDigits(countryCode) {
+			return "", fmt.Errorf("%w: invalid default country code %q", ErrInvalidPhoneNumber, defaultCountryCode)
+		}
+		digits = countryCode + strings.TrimPrefix(digits, "0")
+	}
+
+	if !isDigits(digits) {
+		return "", fmt.Errorf("%w %q: only digits are allowed", ErrInvalidPhoneNumber, number)
+	}
+	if digits[0] == '0' {
+		return "", fmt.Errorf("%w %q: country code can't start with 0", ErrInvalidPhoneNumber, number)
+	}
+	if len(digits) < minDigits || len(digits) > maxDigits {
+		return "", fmt.Errorf("%w %q: must have %d to %d digits", ErrInvalidPhoneNumber, number, minDigits, maxDigits)
+	}
+
+	return "+" + digits, nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package phonenumber
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		number             string
+		defaultCountryCode string
+		want               string
+	}{
+		{number: "+905551234567", want: "+905551234567"},
+		{number: "+90 (555) 123-45-67", want: "+905551234567"},
+		{number: "00905551234567", want: "+905551234567"},
+		{number: "05551234567", defaultCountryCode: "90", want: "+905551234567"},
+		{number: "555 123 45 67", defaultCountryCode: "+90", want: "+905551234567"},
+		{number: "+14155552671", defaultCountryCode: "90", want: "+14155552671"},
+	}
+
+	for _, tt := range tests {
+		got, err := Normalize(tt.number, tt.defaultCountryCode)
+		if err != nil {
+			t.Errorf("Normalize(%q, %q) error = %v", tt.number, tt.defaultCountryCode, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Normalize(%q, %q) = %q, want %q", tt.number, tt.defaultCountryCode, got, tt.want)
+		}
+	}
+}
+
+func TestNormalize_Invalid(t *testing.T) {
+	tests := []struct {
+		number             string
+		defaultCountryCode string
+	}{
+		{number: ""},
+		{number: "05551234567"},
+		{number: "05551234567", defaultCountryCode: "TR"},
+		{number: "+90555ABC4567"},
+		{number: "+0905551234567"},
+		{number: "+90555"},
+		{number: "+9055512345678901"},
+		{number: "user@example.com", defaultCountryCode: "90"},
+	}
+
+	for _, tt := range tests {
+		_, err := Normalize(tt.number, tt.defaultCountryCode)
+		if !errors.Is(err, ErrInvalidPhoneNumber) {
+			t.Errorf("Normalize(%q, %q) error = %v, want %v", tt.number, tt.defaultCountryCode, err, ErrInvalidPhoneNumber)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package retry
+
+import "sync"
+
+// Budget limits the share of retries among all calls, so that a failing
+// dependency isn't flooded with retries by every caller at once.
+//
+// Every call deposits ratio tokens and every retry withdraws one token, the
+// balance is capped at maxTokens. With a ratio of 0.1 retries can add at most
+// 10% load on top of the calls once the initial balance is spent.
+type Budget struct {
+	mu        sync.Mutex
+	tokens    float64
+	maxTokens float64
+	ratio     float64
+}
+
+// NewBudget creates a new Budget starting with maxTokens tokens.
+func NewBudget(maxTokens, ratio float64) *Budget {
+	return &Budget{
+		tokens:    maxTokens,
+		maxTokens: maxTokens,
+		ratio:     ratio,
+	}
+}
+
+// Tokens returns the current balance of the budget.
+func (b *Budget) Tokens() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.tokens
+}
+
+func (b *Budget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += b.ratio
+	if b.tokens > b.maxTokens {
+		b.tokens = b.maxTokens
+	}
+}
+
+func (b *Budget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
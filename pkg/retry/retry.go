@@ -2,16 +2,35 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"math/rand"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 )
 
+const (
+	// maxErrorBodySize limits how much of a failed response body is kept in a StatusError.
+	maxErrorBodySize = 4 << 10
+	// maxDrainSize limits how much of a failed response body is drained to reuse the connection.
+	maxDrainSize = 64 << 10
+)
+
+// ErrBudgetExhausted is returned when a retry is denied by the retry budget.
+var ErrBudgetExhausted = errors.New("retry budget exhausted")
+
+// DefaultBudget is the retry budget shared by the callers of DefaultConfig.
+var DefaultBudget = NewBudget(10, 0.1)
+
 var DefaultConfig = Config{
 	MaxRetries:     5,
 	InitialBackoff: 1 * time.Second,
 	MaxBackoff:     20 * time.Second,
 	BackoffFactor:  2,
+	Budget:         DefaultBudget,
 }
 
 // Config represents the configuration for the retry mechanism.
@@ -20,34 +39,254 @@ type Config struct {
 	InitialBackoff time.Duration
 	MaxBackoff     time.Duration
 	BackoffFactor  int
+
+	// MaxElapsed stops retrying once the next attempt would start after it, zero means no limit.
+	MaxElapsed time.Duration
+	// Budget limits retries across all callers sharing it, nil means no limit.
+	Budget *Budget
+	// OnAttempt is called after every failed attempt, e.g. for logging or metrics.
+	OnAttempt func(Attempt)
+	// Classifier decides whether a failed HTTP attempt is retried, DefaultClassifier is used when nil.
+	// It is only used by Retry, errors of Do are retried unless they are marked with Permanent.
+	Classifier Classifier
 }
 
-// Retry retries the given function until it succeeds or the context is canceled.
-func Retry(ctx context.Context, fn func() (*http.Response, error), config Config) (*http.Response, error) {
-	var finalErr error
+// Attempt describes a failed attempt.
+type Attempt struct {
+	// Number is the 1-based number of the attempt.
+	Number int
+	Err    error
+	// Elapsed is the time since the first attempt started.
+	Elapsed time.Duration
+	// Retry reports whether another attempt follows, after Delay.
+	Retry bool
+	Delay time.Duration
+}
+
+// Classifier reports whether an attempt that returned resp and err is worth retrying.
+// It is only called for failed attempts: err is set or resp has a non-2xx status code.
+type Classifier func(resp *http.Response, err error) bool
+
+// DefaultClassifier retries transport errors and transient status codes
+// (408, 425, 429 and 5xx except 501 and 505). Other responses like 400, 401
+// or 422 won't succeed when repeated and are returned right away.
+//
+// Timeouts of a single attempt, e.g. by http.Client.Timeout, are retried as well;
+// Do stops retrying on its own once the caller's context is done.
+func DefaultClassifier(resp *http.Response, err error) bool {
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) && urlErr.Timeout() {
+			return true
+		}
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+
+	return IsTransientStatus(resp.StatusCode)
+}
+
+// IsTransientStatus reports whether a response with the status code may succeed when repeated:
+// 408, 425, 429 and 5xx except 501 and 505.
+func IsTransientStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests:
+		return true
+	case http.StatusNotImplemented, http.StatusHTTPVersionNotSupported:
+		return false
+	}
+	return code >= 500
+}
+
+// StatusError is returned when the last attempt got a non-2xx response.
+type StatusError struct {
+	StatusCode int
+	// Body holds the beginning of the response body.
+	Body []byte
+	// RetryAfter is the delay requested by the Retry-After header, zero if there was none.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	if len(e.Body) == 0 {
+		return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+	}
+	return fmt.Sprintf("unexpected status code: %d: %s", e.StatusCode, e.Body)
+}
+
+// permanentError marks an error which is not retried by Do.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err so that Do returns it without retrying.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Do calls fn until it succeeds, returns a Permanent error, the retries are
+// exhausted or the context is canceled.
+//
+// The waits between attempts use full jitter exponential backoff, unless the
+// error is a StatusError with a RetryAfter which is honored instead. A retry
+// sooner than RetryAfter is bound to fail, so when it is longer than the
+// config's MaxBackoff, its MaxElapsed or the time left until the context's
+// deadline, the StatusError is returned right away for the caller to decide.
+func Do[T any](ctx context.Context, fn func() (T, error), config Config) (T, error) {
+	var zero T
+	start := time.Now()
 	backoff := config.InitialBackoff
 
-	for i := 0; i < config.MaxRetries; i++ {
-		resp, err := fn()
+	if config.Budget != nil {
+		config.Budget.deposit()
+	}
+
+	for i := 1; ; i++ {
+		result, err := fn()
 		if err == nil {
-			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-				return resp, nil
+			return result, nil
+		}
+		if ctx.Err() != nil {
+			return zero, ctx.Err()
+		}
+
+		var permanent *permanentError
+		retry := !errors.As(err, &permanent) && i < config.MaxRetries
+		if permanent != nil {
+			err = permanent.err
+		}
+
+		delay := jitter(backoff)
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+			delay = statusErr.RetryAfter
+			if config.MaxBackoff > 0 && delay > config.MaxBackoff {
+				retry = false
 			}
-			finalErr = fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-		} else {
-			finalErr = err
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+				retry = false
+			}
+		}
+
+		elapsed := time.Since(start)
+		if retry && config.MaxElapsed > 0 && elapsed+delay > config.MaxElapsed {
+			retry = false
+		}
+		if retry && config.Budget != nil && !config.Budget.withdraw() {
+			retry = false
+			err = fmt.Errorf("%w: %w", ErrBudgetExhausted, err)
+		}
+
+		if config.OnAttempt != nil {
+			config.OnAttempt(Attempt{Number: i, Err: err, Elapsed: elapsed, Retry: retry, Delay: delay})
+		}
+		if !retry {
+			return zero, err
 		}
 
+		timer := time.NewTimer(delay)
 		select {
-		case <-time.After(backoff):
+		case <-timer.C:
 			backoff *= time.Duration(config.BackoffFactor)
 			if backoff > config.MaxBackoff {
 				backoff = config.MaxBackoff
 			}
 		case <-ctx.Done():
-			return nil, ctx.Err()
+			timer.Stop()
+			return zero, ctx.Err()
 		}
 	}
+}
 
-	return nil, finalErr
+// Retry retries the given HTTP request function with Do. Failed responses are
+// turned into a StatusError, retried according to the config's Classifier and
+// their bodies are drained and closed.
+func Retry(ctx context.Context, fn func() (*http.Response, error), config Config) (*http.Response, error) {
+	classify := config.Classifier
+	if classify == nil {
+		classify = DefaultClassifier
+	}
+
+	return Do(ctx, func() (*http.Response, error) {
+		resp, err := fn()
+		if err != nil {
+			if resp != nil {
+				drain(resp.Body)
+			}
+			if !classify(nil, err) {
+				return nil, Permanent(err)
+			}
+			return nil, err
+		}
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return resp, nil
+		}
+
+		retryable := classify(resp, nil)
+		statusErr := newStatusError(resp, time.Now())
+		if !retryable {
+			return nil, Permanent(statusErr)
+		}
+		return nil, statusErr
+	}, config)
+}
+
+// newStatusError builds the StatusError of resp, consuming and closing its body.
+func newStatusError(resp *http.Response, now time.Time) *StatusError {
+	statusErr := &StatusError{StatusCode: resp.StatusCode}
+	statusErr.RetryAfter, _ = retryAfter(resp, now)
+	if resp.Body == nil {
+		return statusErr
+	}
+
+	statusErr.Body, _ = io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	drain(resp.Body)
+	return statusErr
+}
+
+// drain reads the rest of body, so that the connection can be reused, and closes it.
+func drain(body io.ReadCloser) {
+	if body == nil {
+		return
+	}
+	io.Copy(io.Discard, io.LimitReader(body, maxDrainSize))
+	body.Close()
+}
+
+// jitter returns a random duration in [0, backoff).
+func jitter(backoff time.Duration) time.Duration {
+	if backoff <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(backoff)))
+}
+
+// retryAfter parses the Retry-After header of resp, given either in seconds or as an HTTP date.
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if delay := date.Sub(now); delay > 0 {
+		return delay, true
+	}
+	return 0, true
 }
@@ -4,7 +4,10 @@ package retry
 import (
 	"context"
 	"errors"
+	"io"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -106,3 +109,322 @@ func TestRetry_HandlesNon2xxStatusCodes(t *testing.T) {
 		t.Fatalf("expected 3 attempts, got %d", attempts)
 	}
 }
+
+func TestRetry_DoesNotRetryPermanentStatusCodes(t *testing.T) {
+	ctx := context.Background()
+	attempts := 0
+	fn := func() (*http.Response, error) {
+		attempts++
+		return &http.Response{
+			StatusCode: http.StatusUnprocessableEntity,
+			Body:       io.NopCloser(strings.NewReader(`{"error": "invalid recipient"}`)),
+		}, nil
+	}
+
+	resp, err := Retry(ctx, fn, DefaultConfig)
+	if resp != nil {
+		t.Fatalf("expected nil response, got %v", resp)
+	}
+	if attempts != 1 {
+		t.Fatalf("expected 1 attempt, got %d", attempts)
+	}
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("expected StatusError, got %v", err)
+	}
+	if statusErr.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status code 422, got %d", statusErr.StatusCode)
+	}
+	if string(statusErr.Body) != `{"error": "invalid recipient"}` {
+		t.Fatalf("unexpected body %q", statusErr.Body)
+	}
+}
+
+func TestRetry_CustomClassifier(t *testing.T) {
+	ctx := context.Background()
+	attempts := 0
+	fn := func() (*http.Response, error) {
+		attempts++
+		return nil, errors.New("connection refused")
+	}
+
+	_, err := Retry(ctx, fn, Config{
+		MaxRetries:     3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+		BackoffFactor:  2,
+		Classifier:     func(*http.Response, error) bool { return false },
+	})
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+	if attempts != 1 {
+		t.Fatalf("expected 1 attempt, got %d", attempts)
+	}
+}
+
+func TestRetry_HonorsRetryAfter(t *testing.T) {
+	ctx := context.Background()
+	attempts := 0
+	fn := func() (*http.Response, error) {
+		attempts++
+		if attempts == 1 {
+			return &http.Response{
+				StatusCode: http.StatusTooManyRequests,
+				Header:     http.Header{"Retry-After": []string{"1"}},
+			}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}
+
+	start := time.Now()
+	_, err := Retry(ctx, fn, Config{
+		MaxRetries:     2,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		BackoffFactor:  2,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("expected to wait for Retry-After, waited %v", elapsed)
+	}
+}
+
+func TestRetry_GivesUpOnLongRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		ctx    func() (context.Context, context.CancelFunc)
+	}{
+		{
+			name:   "max backoff",
+			config: Config{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond, BackoffFactor: 2},
+			ctx:    func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+		},
+		{
+			name:   "max elapsed",
+			config: Config{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Hour, BackoffFactor: 2, MaxElapsed: time.Minute},
+			ctx:    func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+		},
+		{
+			name:   "context deadline",
+			config: Config{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Hour, BackoffFactor: 2},
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), time.Minute)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			attempts := 0
+			fn := func() (*http.Response, error) {
+				attempts++
+				return &http.Response{
+					StatusCode: http.StatusTooManyRequests,
+					Header:     http.Header{"Retry-After": []string{"3600"}},
+				}, nil
+			}
+
+			start := time.Now()
+			_, err := Retry(ctx, fn, tt.config)
+			var statusErr *StatusError
+			if !errors.As(err, &statusErr) || statusErr.RetryAfter != time.Hour {
+				t.Fatalf("expected a StatusError with the Retry-After, got %v", err)
+			}
+			if attempts != 1 {
+				t.Errorf("expected a single attempt, got %d", attempts)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("expected to give up right away, waited %v", elapsed)
+			}
+		})
+	}
+}
+
+func TestRetry_RetriesAttemptTimeouts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	client := &http.Client{Timeout: 10 * time.Millisecond}
+
+	attempts := 0
+	fn := func() (*http.Response, error) {
+		attempts++
+		return client.Get(server.URL)
+	}
+
+	_, err := Retry(context.Background(), fn, Config{
+		MaxRetries:     3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+		BackoffFactor:  2,
+	})
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+	if attempts != 3 {
+		t.Errorf("expected the timed out attempts to be retried 3 times, got %d", attempts)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{value: "", ok: false},
+		{value: "3", want: 3 * time.Second, ok: true},
+		{value: "-1", ok: false},
+		{value: now.Add(10 * time.Second).Format(http.TimeFormat), want: 10 * time.Second, ok: true},
+		{value: now.Add(-time.Minute).Format(http.TimeFormat), want: 0, ok: true},
+		{value: "soon", ok: false},
+	}
+
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if tt.value != "" {
+			resp.Header.Set("Retry-After", tt.value)
+		}
+
+		got, ok := retryAfter(resp, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+var fastConfig = Config{
+	MaxRetries:     5,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     time.Millisecond,
+	BackoffFactor:  2,
+}
+
+func TestDo_ReportsAttempts(t *testing.T) {
+	config := fastConfig
+	var attempts []Attempt
+	config.OnAttempt = func(a Attempt) { attempts = append(attempts, a) }
+
+	calls := 0
+	result, err := Do(context.Background(), func() (string, error) {
+		calls++
+		if calls < 3 {
+			return "", errors.New("temporary error")
+		}
+		return "done", nil
+	}, config)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result != "done" {
+		t.Fatalf("expected result done, got %q", result)
+	}
+	if len(attempts) != 2 {
+		t.Fatalf("expected 2 failed attempts, got %d", len(attempts))
+	}
+	for i, a := range attempts {
+		if a.Number != i+1 || !a.Retry || a.Err == nil {
+			t.Fatalf("unexpected attempt %+v", a)
+		}
+	}
+}
+
+func TestDo_Permanent(t *testing.T) {
+	errInvalid := errors.New("invalid")
+	calls := 0
+	_, err := Do(context.Background(), func() (int, error) {
+		calls++
+		return 0, Permanent(errInvalid)
+	}, fastConfig)
+	if err != errInvalid {
+		t.Fatalf("expected %v, got %v", errInvalid, err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected 1 call, got %d", calls)
+	}
+}
+
+func TestDo_MaxElapsed(t *testing.T) {
+	config := fastConfig
+	config.MaxRetries = 100
+	config.InitialBackoff = 20 * time.Millisecond
+	config.MaxBackoff = 20 * time.Millisecond
+	config.MaxElapsed = 50 * time.Millisecond
+
+	start := time.Now()
+	_, err := Do(context.Background(), func() (int, error) {
+		return 0, errors.New("temporary error")
+	}, config)
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Fatalf("expected to stop after about 50ms, took %v", elapsed)
+	}
+}
+
+func TestDo_Budget(t *testing.T) {
+	config := fastConfig
+	config.Budget = NewBudget(2, 0)
+
+	calls := 0
+	_, err := Do(context.Background(), func() (int, error) {
+		calls++
+		return 0, errors.New("temporary error")
+	}, config)
+	if !errors.Is(err, ErrBudgetExhausted) {
+		t.Fatalf("expected %v, got %v", ErrBudgetExhausted, err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 calls, got %d", calls)
+	}
+	if tokens := config.Budget.Tokens(); tokens != 0 {
+		t.Fatalf("expected empty budget, got %v", tokens)
+	}
+}
+
+type trackingBody struct {
+	io.Reader
+	closed bool
+}
+
+func (b *trackingBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestRetry_ClosesFailedResponses(t *testing.T) {
+	var bodies []*trackingBody
+	attempts := 0
+	fn := func() (*http.Response, error) {
+		attempts++
+		if attempts < 3 {
+			body := &trackingBody{Reader: strings.NewReader("unavailable")}
+			bodies = append(bodies, body)
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: body}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}
+
+	if _, err := Retry(context.Background(), fn, fastConfig); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for i, body := range bodies {
+		if !body.closed {
+			t.Fatalf("body of attempt %d is not closed", i+1)
+		}
+	}
+}
@@ -0,0 +1,356 @@
+package notification
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSignatureHeader carries the hex encoded HMAC-SHA256 signature of the request.
+	DefaultSignatureHeader = "X-Signature"
+	// DefaultTimestampHeader carries the unix timestamp the signature is computed with.
+	DefaultTimestampHeader = "X-Timestamp"
+)
+
+// tokenExpirySkew renews cached OAuth2 tokens this long before they expire.
+const tokenExpirySkew = 30 * time.Second
+
+// Authenticator authenticates outbound notification requests.
+// It is called for every attempt, so the credentials can be refreshed between retries.
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// Invalidator is implemented by authenticators caching credentials which the server
+// may revoke before they expire, e.g. OAuth2 tokens.
+type Invalidator interface {
+	// Invalidate drops the cached credentials req was authenticated with after the server
+	// answered it with 401. It reports whether authenticating again may use new credentials.
+	Invalidate(req *http.Request) bool
+}
+
+// Make sure the authentication strategies implement Authenticator interface.
+var (
+	_ Authenticator = HeaderAuth{}
+	_ Authenticator = BearerAuth{}
+	_ Authenticator = BasicAuth{}
+	_ Authenticator = (*OAuth2ClientCredentials)(nil)
+	_ Authenticator = HMACSigner{}
+	_ Authenticator = ChainAuth{}
+
+	_ Invalidator = (*OAuth2ClientCredentials)(nil)
+	_ Invalidator = ChainAuth{}
+)
+
+// HeaderAuth sets a static header, e.g. an API key.
+type HeaderAuth struct {
+	Name  string
+	Value string
+}
+
+func (a HeaderAuth) Authenticate(req *http.Request) error {
+	req.Header.Set(a.Name, a.Value)
+	return nil
+}
+
+// BearerAuth sets a static bearer token.
+type BearerAuth struct {
+	Token string
+}
+
+func (a BearerAuth) Authenticate(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+a.Token)
+	return nil
+}
+
+// BasicAuth sets HTTP basic authentication credentials.
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+func (a BasicAuth) Authenticate(req *http.Request) error {
+	req.SetBasicAuth(a.Username, a.Password)
+	return nil
+}
+
+// OAuth2ClientCredentials obtains bearer tokens with the OAuth2 client credentials grant
+// and caches them until shortly before they expire, or until the server rejects them.
+type OAuth2ClientCredentials struct {
+	client       *http.Client
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       []string
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// NewOAuth2ClientCredentials initializes a new OAuth2ClientCredentials instance.
+func NewOAuth2ClientCredentials(tokenURL, clientID, clientSecret string, scopes []string, timeout time.Duration) *OAuth2ClientCredentials {
+	return &OAuth2ClientCredentials{
+		client: &http.Client{
+			Timeout: timeout,
+		},
+		tokenURL:     tokenURL,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		scopes:       scopes,
+	}
+}
+
+func (a *OAuth2ClientCredentials) Authenticate(req *http.Request) error {
+	token, err := a.Token(req.Context())
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Invalidate drops the cached token if req was authenticated with it, so that a revoked or
+// rotated token is replaced right away. Another request may have replaced it already.
+func (a *OAuth2ClientCredentials) Invalidate(req *http.Request) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && req.Header.Get("Authorization") == "Bearer "+a.token {
+		a.token = ""
+	}
+	return true
+}
+
+// oauth2TokenResponse represents the response of the token endpoint.
+type oauth2TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// Token returns the cached access token, requesting a new one when it is missing or about to expire.
+func (a *OAuth2ClientCredentials) Token(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && time.Now().Before(a.expiry) {
+		return a.token, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(a.scopes) > 0 {
+		form.Set("scope", strings.Join(a.scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(a.clientID), url.QueryEscape(a.clientSecret))
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("token request failed with status code: %d", resp.StatusCode)
+	}
+
+	var token oauth2TokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("token response has no access token")
+	}
+
+	a.token = token.AccessToken
+	a.expiry = time.Now().Add(tokenTTL(token.ExpiresIn))
+
+	return a.token, nil
+}
+
+// tokenTTL returns how long a token expiring in expiresIn seconds is cached. It is renewed
+// tokenExpirySkew before it expires, but short lived tokens are used for half of their lifetime
+// at least. Tokens without an expiry are cached for a minute only, so revocations are picked up.
+func tokenTTL(expiresIn int) time.Duration {
+	if expiresIn <= 0 {
+		return time.Minute
+	}
+
+	lifetime := time.Duration(expiresIn) * time.Second
+	return max(lifetime-tokenExpirySkew, lifetime/2)
+}
+
+// HMACSigner signs the requests for webhook receivers. The signature is the hex encoded
+// HMAC-SHA256 of "<timestamp>.<body>" with the shared secret, where timestamp is the unix
+// time sent in the timestamp header. Receivers should reject stale timestamps to prevent replays.
+type HMACSigner struct {
+	Secret []byte
+	// SignatureHeader and TimestampHeader default to DefaultSignatureHeader and DefaultTimestampHeader.
+	SignatureHeader string
+	TimestampHeader string
+}
+
+func (s HMACSigner) Authenticate(req *http.Request) error {
+	var body []byte
+	if req.GetBody != nil {
+		r, err := req.GetBody()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		body, err = io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	signatureHeader := s.SignatureHeader
+	if signatureHeader == "" {
+		signatureHeader = DefaultSignatureHeader
+	}
+	timestampHeader := s.TimestampHeader
+	if timestampHeader == "" {
+		timestampHeader = DefaultTimestampHeader
+	}
+
+	req.Header.Set(timestampHeader, timestamp)
+	req.Header.Set(signatureHeader, Sign(s.Secret, timestamp, body))
+
+	return nil
+}
+
+// Sign returns the hex encoded HMAC-SHA256 signature of the body at the given timestamp.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the signature of the body at the given timestamp.
+// Callers should also reject stale timestamps to prevent replays.
+func Verify(secret []byte, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// ChainAuth applies several authenticators in order, e.g. a bearer token and a signature.
+type ChainAuth []Authenticator
+
+func (c ChainAuth) Authenticate(req *http.Request) error {
+	for _, a := range c {
+		if err := a.Authenticate(req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Invalidate invalidates the credentials of every authenticator in the chain that caches them.
+func (c ChainAuth) Invalidate(req *http.Request) bool {
+	invalidated := false
+	for _, a := range c {
+		if i, ok := a.(Invalidator); ok && i.Invalidate(req) {
+			invalidated = true
+		}
+	}
+	return invalidated
+}
+
+// AuthType represents the authentication strategy of the notification requests.
+type AuthType string
+
+const (
+	AuthTypeNone   AuthType = "none"
+	AuthTypeHeader AuthType = "header"
+	AuthTypeBearer AuthType = "bearer"
+	AuthTypeBasic  AuthType = "basic"
+	AuthTypeOAuth2 AuthType = "oauth2"
+)
+
+// AuthConfig represents the configuration of the authentication of the notification requests.
+type AuthConfig struct {
+	Type AuthType
+	// HeaderName and Token are used by the header strategy, Token by the bearer strategy as well.
+	HeaderName string
+	Token      string
+	// Username and Password are used by the basic strategy.
+	Username string
+	Password string
+	// OAuth2 client credentials grant.
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// HMACSecret signs the requests in addition to the strategy when it is set.
+	HMACSecret string
+}
+
+// NewAuthenticator creates the authenticator of the given configuration.
+// It returns nil when neither authentication nor signing is configured.
+func NewAuthenticator(cfg AuthConfig, timeout time.Duration) (Authenticator, error) {
+	var chain ChainAuth
+
+	switch cfg.Type {
+	case "", AuthTypeNone:
+	case AuthTypeHeader:
+		if cfg.HeaderName == "" || cfg.Token == "" {
+			return nil, errors.New("header auth requires a header name and a token")
+		}
+		chain = append(chain, HeaderAuth{Name: cfg.HeaderName, Value: cfg.Token})
+	case AuthTypeBearer:
+		if cfg.Token == "" {
+			return nil, errors.New("bearer auth requires a token")
+		}
+		chain = append(chain, BearerAuth{Token: cfg.Token})
+	case AuthTypeBasic:
+		if cfg.Username == "" {
+			return nil, errors.New("basic auth requires a username")
+		}
+		chain = append(chain, BasicAuth{Username: cfg.Username, Password: cfg.Password})
+	case AuthTypeOAuth2:
+		if cfg.TokenURL == "" || cfg.ClientID == "" {
+			return nil, errors.New("oauth2 auth requires a token url and a client id")
+		}
+		chain = append(chain, NewOAuth2ClientCredentials(cfg.TokenURL, cfg.ClientID, cfg.ClientSecret, cfg.Scopes, timeout))
+	default:
+		return nil, fmt.Errorf("unknown auth type %q", cfg.Type)
+	}
+
+	if cfg.HMACSecret != "" {
+		chain = append(chain, HMACSigner{Secret: []byte(cfg.HMACSecret)})
+	}
+
+	switch len(chain) {
+	case 0:
+		return nil, nil
+	case 1:
+		return chain[0], nil
+	}
+
+	return chain, nil
+}
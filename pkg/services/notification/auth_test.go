@@ -0,0 +1,182 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNotificationService_Authentication(t *testing.T) {
+	tests := []struct {
+		name  string
+		auth  Authenticator
+		check func(r *http.Request) bool
+	}{
+		{
+			name:  "static header",
+			auth:  HeaderAuth{Name: "X-Api-Key", Value: "secret"},
+			check: func(r *http.Request) bool { return r.Header.Get("X-Api-Key") == "secret" },
+		},
+		{
+			name:  "bearer",
+			auth:  BearerAuth{Token: "token"},
+			check: func(r *http.Request) bool { return r.Header.Get("Authorization") == "Bearer token" },
+		},
+		{
+			name: "basic",
+			auth: BasicAuth{Username: "user", Password: "pass"},
+			check: func(r *http.Request) bool {
+				username, password, ok := r.BasicAuth()
+				return ok && username == "user" && password == "pass"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !tt.check(r) {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.Write([]byte(`{"message": "Accepted", "messageId": "1"}`))
+			}))
+			defer server.Close()
+
+			ns := NewNotificationService(server.URL, time.Second, WithAuthenticator(tt.auth))
+			if _, err := ns.Send(context.Background(), "+905555555555", "hello"); err != nil {
+				t.Errorf("Send() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestHMACSigner(t *testing.T) {
+	secret := []byte("shared-secret")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp := r.Header.Get(DefaultTimestampHeader)
+		if timestamp == "" || r.Header.Get(DefaultSignatureHeader) != Sign(secret, timestamp, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"message": "Accepted", "messageId": "1"}`))
+	}))
+	defer server.Close()
+
+	ns := NewNotificationService(server.URL, time.Second, WithAuthenticator(HMACSigner{Secret: secret}))
+	if _, err := ns.Send(context.Background(), "+905555555555", "hello"); err != nil {
+		t.Errorf("Send() error = %v", err)
+	}
+}
+
+func TestOAuth2ClientCredentials_CachesToken(t *testing.T) {
+	var tokenRequests int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&tokenRequests, 1)
+		clientID, clientSecret, _ := r.BasicAuth()
+		r.ParseForm()
+		if clientID != "client" || clientSecret != "secret" || r.Form.Get("grant_type") != "client_credentials" || r.Form.Get("scope") != "sms send" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"access_token": "access", "token_type": "Bearer", "expires_in": 3600}`))
+	}))
+	defer tokenServer.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"message": "Accepted", "messageId": "1"}`))
+	}))
+	defer server.Close()
+
+	auth := NewOAuth2ClientCredentials(tokenServer.URL, "client", "secret", []string{"sms", "send"}, time.Second)
+	ns := NewNotificationService(server.URL, time.Second, WithAuthenticator(auth))
+	for i := 0; i < 3; i++ {
+		if _, err := ns.Send(context.Background(), "+905555555555", "hello"); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	if n := atomic.LoadInt32(&tokenRequests); n != 1 {
+		t.Errorf("expected the token to be requested once, got %d", n)
+	}
+}
+
+func TestNewAuthenticator(t *testing.T) {
+	auth, err := NewAuthenticator(AuthConfig{Type: AuthTypeNone}, time.Second)
+	if err != nil || auth != nil {
+		t.Errorf("NewAuthenticator(none) = %v, %v, want nil, nil", auth, err)
+	}
+
+	auth, err = NewAuthenticator(AuthConfig{Type: AuthTypeBearer, Token: "token", HMACSecret: "secret"}, time.Second)
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+	if chain, ok := auth.(ChainAuth); !ok || len(chain) != 2 {
+		t.Errorf("expected bearer and signature to be chained, got %#v", auth)
+	}
+
+	if _, err := NewAuthenticator(AuthConfig{Type: AuthTypeBearer}, time.Second); err == nil {
+		t.Errorf("expected error for a bearer auth without token")
+	}
+	if _, err := NewAuthenticator(AuthConfig{Type: "digest"}, time.Second); err == nil {
+		t.Errorf("expected error for an unknown auth type")
+	}
+}
+
+func TestOAuth2ClientCredentials_RenewsRejectedToken(t *testing.T) {
+	var tokenRequests int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&tokenRequests, 1)
+		fmt.Fprintf(w, `{"access_token": "access-%d", "token_type": "Bearer", "expires_in": 3600}`, n)
+	}))
+	defer tokenServer.Close()
+
+	// The first token is revoked right after it is issued.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"message": "Accepted", "messageId": "1"}`))
+	}))
+	defer server.Close()
+
+	auth := NewOAuth2ClientCredentials(tokenServer.URL, "client", "secret", nil, time.Second)
+	ns := NewNotificationService(server.URL, time.Second, WithAuthenticator(auth))
+	for i := 0; i < 2; i++ {
+		if _, err := ns.Send(context.Background(), "+905555555555", "hello"); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	if n := atomic.LoadInt32(&tokenRequests); n != 2 {
+		t.Errorf("expected the token to be requested twice, got %d", n)
+	}
+}
+
+func TestTokenTTL(t *testing.T) {
+	tests := []struct {
+		expiresIn int
+		want      time.Duration
+	}{
+		{0, time.Minute},
+		{3600, time.Hour - tokenExpirySkew},
+		{40, 20 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := tokenTTL(tt.expiresIn); got != tt.want {
+			t.Errorf("tokenTTL(%d) = %v, want %v", tt.expiresIn, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"sync"
+	"time"
+
+	"github.com/mehmetalisavas/message-sender/pkg/retry"
+)
+
+// Make sure CircuitBreaker implements NotificationSender interface.
+var _ NotificationSender = (*CircuitBreaker)(nil)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// Circuit breaker states are exposed through expvar under "circuit_breakers".
+var breakerMetrics = expvar.NewMap("circuit_breakers")
+
+// CircuitOpenError is returned when a send is short-circuited by an open circuit breaker.
+type CircuitOpenError struct {
+	Name string
+	// RetryAfter is the remaining time until the breaker lets a trial request through.
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s: %v, retry after %s", e.Name, ErrCircuitOpen, e.RetryAfter)
+}
+
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+type BreakerState string
+
+const (
+	// BreakerStateClosed lets every request through.
+	BreakerStateClosed BreakerState = "closed"
+	// BreakerStateOpen short-circuits every request until the open timeout elapses.
+	BreakerStateOpen BreakerState = "open"
+	// BreakerStateHalfOpen lets a limited number of trial requests through to probe the provider.
+	BreakerStateHalfOpen BreakerState = "half-open"
+)
+
+// BreakerConfig represents the configuration of a circuit breaker.
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the circuit.
+	FailureThreshold int
+	// OpenTimeout is how long the circuit stays open before a trial request is let through.
+	OpenTimeout time.Duration
+	// HalfOpenMaxRequests is the number of concurrent trial requests in the half-open state.
+	HalfOpenMaxRequests int
+}
+
+// halfOpenRetryAfter is suggested to the callers rejected while the trial requests are in flight.
+const halfOpenRetryAfter = time.Second
+
+var DefaultBreakerConfig = BreakerConfig{
+	FailureThreshold:    5,
+	OpenTimeout:         30 * time.Second,
+	HalfOpenMaxRequests: 1,
+}
+
+// BreakerStatus represents the observable status of a circuit breaker.
+type BreakerStatus struct {
+	Name                string       `json:"name"`
+	State               BreakerState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	OpenedAt            *time.Time   `json:"opened_at,omitempty"`
+}
+
+// CircuitBreaker wraps a NotificationSender and stops calling it after consecutive failures,
+// so an unhealthy provider is not hammered with requests that are bound to fail.
+type CircuitBreaker struct {
+	name   string
+	sender NotificationSender
+	cfg    BreakerConfig
+	now    func() time.Time
+
+	mu               sync.Mutex
+	state            BreakerState
+	failures         int
+	openedAt         time.Time
+	halfOpenInFlight int
+}
+
+// NewCircuitBreaker creates a new closed CircuitBreaker around the given sender.
+func NewCircuitBreaker(name string, sender NotificationSender, cfg BreakerConfig) *CircuitBreaker {
+	cb := &CircuitBreaker{
+		name:   name,
+		sender: sender,
+		cfg:    cfg,
+		now:    time.Now,
+		state:  BreakerStateClosed,
+	}
+	breakerMetrics.Set(name, expvar.Func(func() any { return cb.Status() }))
+
+	return cb
+}
+
+// Send sends the notification unless the circuit is open.
+// Only failures telling that the provider is unhealthy are counted, see isProviderFailure.
+func (cb *CircuitBreaker) Send(ctx context.Context, recipient, content string) (*NotificationResponse, error) {
+	if err := cb.allow(); err != nil {
+		return nil, err
+	}
+
+	resp, err := cb.sender.Send(ctx, recipient, content)
+	cb.record(err == nil, err != nil && !isProviderFailure(ctx, err))
+
+	return resp, err
+}
+
+// isProviderFailure reports whether err tells that the provider is unhealthy. Only timeouts, transport
+// errors and transient replies (e.g. HTTP 429 or 5xx, SMTP 4xx) are failures. Anything else says
+// nothing about the provider's health: the caller canceling the context, permanent rejections
+// (e.g. HTTP 400 or 401, SMTP 5xx), rejections in successful responses, responses without a
+// message ID and recipients rejected before the request is sent.
+func isProviderFailure(ctx context.Context, err error) bool {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return false
+	}
+
+	var statusErr *retry.StatusError
+	if errors.As(err, &statusErr) {
+		return retry.IsTransientStatus(statusErr.StatusCode)
+	}
+	var replyErr *textproto.Error
+	if errors.As(err, &replyErr) {
+		return replyErr.Code >= 400 && replyErr.Code < 500
+	}
+
+	// Timeouts, including context.DeadlineExceeded, and transport errors are net.Errors.
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// Name returns the name of the breaker.
+func (cb *CircuitBreaker) Name() string {
+	return cb.name
+}
+
+// Status returns the current status of the breaker.
+func (cb *CircuitBreaker) Status() BreakerStatus {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	status := BreakerStatus{
+		Name:                cb.name,
+		State:               cb.currentState(),
+		ConsecutiveFailures: cb.failures,
+	}
+	if status.State != BreakerStateClosed {
+		openedAt := cb.openedAt
+		status.OpenedAt = &openedAt
+	}
+
+	return status
+}
+
+// allow reports whether a request can go through, reserving a trial slot in the half-open state.
+func (cb *CircuitBreaker) allow() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.currentState() {
+	case BreakerStateOpen:
+		return &CircuitOpenError{Name: cb.name, RetryAfter: cb.openedAt.Add(cb.cfg.OpenTimeout).Sub(cb.now())}
+	case BreakerStateHalfOpen:
+		if cb.halfOpenInFlight >= cb.cfg.HalfOpenMaxRequests {
+			return &CircuitOpenError{Name: cb.name, RetryAfter: halfOpenRetryAfter}
+		}
+		cb.state = BreakerStateHalfOpen
+		cb.halfOpenInFlight++
+	}
+
+	return nil
+}
+
+// record updates the breaker with the result of a request.
+// Ignored results only release the trial slot of the half-open state.
+func (cb *CircuitBreaker) record(success, ignored bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	wasHalfOpen := cb.state == BreakerStateHalfOpen
+	if wasHalfOpen {
+		cb.halfOpenInFlight--
+	}
+
+	switch {
+	case success:
+		cb.failures = 0
+		cb.state = BreakerStateClosed
+	case ignored:
+		// The provider's health is unknown, let another request probe it.
+	case wasHalfOpen:
+		cb.trip()
+	default:
+		cb.failures++
+		if cb.failures >= cb.cfg.FailureThreshold {
+			cb.trip()
+		}
+	}
+}
+
+// trip opens the circuit. cb.mu must be held.
+func (cb *CircuitBreaker) trip() {
+	cb.state = BreakerStateOpen
+	cb.openedAt = cb.now()
+	cb.halfOpenInFlight = 0
+}
+
+// currentState returns the state, taking the open timeout into account. cb.mu must be held.
+func (cb *CircuitBreaker) currentState() BreakerState {
+	if cb.state == BreakerStateOpen && !cb.now().Before(cb.openedAt.Add(cb.cfg.OpenTimeout)) {
+		return BreakerStateHalfOpen
+	}
+	return cb.state
+}
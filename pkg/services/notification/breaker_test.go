@@ -0,0 +1,175 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/mehmetalisavas/message-sender/pkg/retry"
+)
+
+func TestCircuitBreaker_OpensAfterConsecutiveFailures(t *testing.T) {
+	calls := 0
+	sender := funcSender(func(ctx context.Context, recipient, content string) (*NotificationResponse, error) {
+		calls++
+		return nil, errProviderDown
+	})
+	cb := NewCircuitBreaker("test-open", sender, BreakerConfig{FailureThreshold: 3, OpenTimeout: time.Minute, HalfOpenMaxRequests: 1})
+
+	for i := 0; i < 3; i++ {
+		if _, err := cb.Send(context.Background(), "+905555555555", "hello"); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("breaker opened after %d failures, want 3", i)
+		}
+	}
+	if state := cb.Status().State; state != BreakerStateOpen {
+		t.Fatalf("expected state %s, got %s", BreakerStateOpen, state)
+	}
+
+	_, err := cb.Send(context.Background(), "+905555555555", "hello")
+	var openErr *CircuitOpenError
+	if !errors.As(err, &openErr) || !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected circuit open error, got %v", err)
+	}
+	if openErr.RetryAfter <= 0 || openErr.RetryAfter > time.Minute {
+		t.Errorf("unexpected retry after %s", openErr.RetryAfter)
+	}
+	if calls != 3 {
+		t.Errorf("expected the sender to be called 3 times, got %d", calls)
+	}
+}
+
+func TestCircuitBreaker_HalfOpen(t *testing.T) {
+	fail := true
+	sender := funcSender(func(ctx context.Context, recipient, content string) (*NotificationResponse, error) {
+		if fail {
+			return nil, errProviderDown
+		}
+		return &NotificationResponse{MessageID: "1"}, nil
+	})
+	cb := NewCircuitBreaker("test-half-open", sender, BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenMaxRequests: 1})
+	now := time.Now()
+	cb.now = func() time.Time { return now }
+
+	cb.Send(context.Background(), "+905555555555", "hello")
+	if state := cb.Status().State; state != BreakerStateOpen {
+		t.Fatalf("expected state %s, got %s", BreakerStateOpen, state)
+	}
+
+	// A failing trial request opens the circuit again.
+	now = now.Add(time.Minute)
+	if state := cb.Status().State; state != BreakerStateHalfOpen {
+		t.Fatalf("expected state %s, got %s", BreakerStateHalfOpen, state)
+	}
+	if _, err := cb.Send(context.Background(), "+905555555555", "hello"); errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected a trial request in half-open state, got %v", err)
+	}
+	if state := cb.Status().State; state != BreakerStateOpen {
+		t.Fatalf("expected state %s, got %s", BreakerStateOpen, state)
+	}
+
+	// A successful trial request closes the circuit.
+	now = now.Add(time.Minute)
+	fail = false
+	if _, err := cb.Send(context.Background(), "+905555555555", "hello"); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if status := cb.Status(); status.State != BreakerStateClosed || status.ConsecutiveFailures != 0 {
+		t.Errorf("expected closed breaker without failures, got %+v", status)
+	}
+}
+
+func TestRouter_AllCircuitsOpen(t *testing.T) {
+	cfg := BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenMaxRequests: 1}
+	primary := NewCircuitBreaker("test-router-primary", failingSender(), cfg)
+	backup := NewCircuitBreaker("test-router-backup", failingSender(), cfg)
+	router := NewRouter([]Route{{
+		Providers: []Provider{
+			{Name: "primary", Sender: primary, Priority: 1},
+			{Name: "backup", Sender: backup, Priority: 2},
+		},
+	}})
+
+	// The first send trips both breakers.
+	if _, err := router.Send(context.Background(), "+905555555555", "hello"); errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected provider errors, got %v", err)
+	}
+
+	_, err := router.Send(context.Background(), "+905555555555", "hello")
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Send() error = %v, want %v", err, ErrCircuitOpen)
+	}
+}
+
+func TestCircuitBreaker_CountsOnlyProviderFailures(t *testing.T) {
+	var sendErr error
+	sender := funcSender(func(ctx context.Context, recipient, content string) (*NotificationResponse, error) {
+		if sendErr == context.DeadlineExceeded {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return nil, sendErr
+	})
+	cb := NewCircuitBreaker("test-provider-failures", sender, BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenMaxRequests: 1})
+
+	// Bad requests, rejected recipients, responses without a message ID and callers giving up don't open the circuit.
+	ignored := []error{
+		&retry.StatusError{StatusCode: 400},
+		&retry.StatusError{StatusCode: 401},
+		fmt.Errorf("%w: success is %q: invalid number", ErrProviderRejected, "false"),
+		ErrMissingMessageID,
+		fmt.Errorf("invalid email recipient: %w", errors.New("mail: no angle-addr")),
+		&textproto.Error{Code: 550, Msg: "mailbox unavailable"},
+	}
+	for _, err := range ignored {
+		sendErr = err
+		cb.Send(context.Background(), "+905555555555", "hello")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sendErr = context.Canceled
+	cb.Send(ctx, "+905555555555", "hello")
+	if status := cb.Status(); status.State != BreakerStateClosed || status.ConsecutiveFailures != 0 {
+		t.Fatalf("expected closed breaker without failures, got %+v", status)
+	}
+
+	// A provider that hangs until the send times out does.
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	sendErr = context.DeadlineExceeded
+	cb.Send(ctx, "+905555555555", "hello")
+	if state := cb.Status().State; state != BreakerStateOpen {
+		t.Errorf("expected state %s, got %s", BreakerStateOpen, state)
+	}
+}
+
+func TestIsProviderFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"transport error", errProviderDown, true},
+		{"attempt timeout", &url.Error{Op: "Post", URL: "http://provider.test", Err: context.DeadlineExceeded}, true},
+		{"send timeout", context.DeadlineExceeded, true},
+		{"connection closed", io.ErrUnexpectedEOF, true},
+		{"transient status", &retry.StatusError{StatusCode: 503}, true},
+		{"rate limited", &retry.StatusError{StatusCode: 429}, true},
+		{"transient smtp reply", &textproto.Error{Code: 421, Msg: "service not available"}, true},
+		{"permanent status", &retry.StatusError{StatusCode: 422}, false},
+		{"permanent smtp reply", &textproto.Error{Code: 550, Msg: "mailbox unavailable"}, false},
+		{"rejected by provider", ErrProviderRejected, false},
+		{"missing message id", ErrMissingMessageID, false},
+		{"local validation", errors.New("invalid email recipient"), false},
+	}
+
+	for _, tt := range tests {
+		if got := isProviderFailure(context.Background(), tt.err); got != tt.want {
+			t.Errorf("isProviderFailure(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
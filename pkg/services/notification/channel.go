@@ -0,0 +1,64 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrUnsupportedChannel = errors.New("unsupported notification channel")
+
+// Channel represents a delivery channel of notifications.
+type Channel string
+
+const (
+	ChannelSMS   Channel = "sms"
+	ChannelEmail Channel = "email"
+	ChannelPush  Channel = "push"
+	ChannelChat  Channel = "chat"
+)
+
+// Registry holds the notification senders of the channels.
+type Registry struct {
+	mu      sync.RWMutex
+	senders map[Channel]NotificationSender
+}
+
+// NewRegistry creates a new Registry instance.
+func NewRegistry() *Registry {
+	return &Registry{
+		senders: make(map[Channel]NotificationSender),
+	}
+}
+
+// Register registers the sender of the given channel, replacing the existing one.
+func (r *Registry) Register(channel Channel, sender NotificationSender) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.senders[channel] = sender
+}
+
+// Sender returns the sender of the given channel.
+func (r *Registry) Sender(channel Channel) (NotificationSender, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sender, ok := r.senders[channel]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedChannel, channel)
+	}
+
+	return sender, nil
+}
+
+// Send sends the notification through the sender of the given channel.
+func (r *Registry) Send(ctx context.Context, channel Channel, recipient, content string) (*NotificationResponse, error) {
+	sender, err := r.Sender(channel)
+	if err != nil {
+		return nil, err
+	}
+
+	return sender.Send(ctx, recipient, content)
+}
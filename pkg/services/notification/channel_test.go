@@ -0,0 +1,104 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type stubSender struct {
+	recipient string
+	content   string
+}
+
+func (s *stubSender) Send(ctx context.Context, recipient, content string) (*NotificationResponse, error) {
+	s.recipient = recipient
+	s.content = content
+	return &NotificationResponse{MessageID: "stub"}, nil
+}
+
+func TestRegistry_Send(t *testing.T) {
+	registry := NewRegistry()
+	sms := &stubSender{}
+	registry.Register(ChannelSMS, sms)
+
+	resp, err := registry.Send(context.Background(), ChannelSMS, "+905555555555", "hello")
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if resp.MessageID != "stub" || sms.recipient != "+905555555555" || sms.content != "hello" {
+		t.Errorf("Send() routed to the wrong sender: %+v, %+v", resp, sms)
+	}
+
+	_, err = registry.Send(context.Background(), ChannelEmail, "user@example.com", "hello")
+	if !errors.Is(err, ErrUnsupportedChannel) {
+		t.Errorf("Send() error = %v, want %v", err, ErrUnsupportedChannel)
+	}
+}
+
+func TestChatWebhookSender_Send(t *testing.T) {
+	var received chatWebhookRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	sender := NewChatWebhookSender(server.URL, time.Second)
+	resp, err := sender.Send(context.Background(), "#alerts", "deploy finished")
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if received.Channel != "#alerts" || received.Text != "deploy finished" {
+		t.Errorf("unexpected webhook payload: %+v", received)
+	}
+	if resp.MessageID == "" {
+		t.Errorf("expected a generated message ID")
+	}
+}
+
+func TestPushSender_Send(t *testing.T) {
+	var received PushRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Write([]byte(`{"id": "push-1", "status": "queued"}`))
+	}))
+	defer server.Close()
+
+	sender := NewPushSender(server.URL, "Reminder", time.Second)
+	resp, err := sender.Send(context.Background(), "device-token", "hello")
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if received.Token != "device-token" || received.Title != "Reminder" || received.Body != "hello" {
+		t.Errorf("unexpected push payload: %+v", received)
+	}
+	if resp.MessageID != "push-1" {
+		t.Errorf("Send() message ID = %q, want %q", resp.MessageID, "push-1")
+	}
+}
+
+func TestSMSGatewaySender_Send(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("to") != "+905555555555" || r.Form.Get("text") != "hello" || r.Form.Get("from") != "INSIDER" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"message_id": "sms-1"}`))
+	}))
+	defer server.Close()
+
+	sender := NewSMSGatewaySender(server.URL, "INSIDER", time.Second)
+	resp, err := sender.Send(context.Background(), "+905555555555", "hello")
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if resp.MessageID != "sms-1" {
+		t.Errorf("Send() message ID = %q, want %q", resp.MessageID, "sms-1")
+	}
+}
@@ -0,0 +1,62 @@
+package notification
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Make sure ChatWebhookSender implements NotificationSender interface.
+var _ NotificationSender = (*ChatWebhookSender)(nil)
+
+// ChatWebhookSender posts messages to Slack/Teams-style incoming webhooks.
+type ChatWebhookSender struct {
+	client     *http.Client
+	webhookURL string
+}
+
+// NewChatWebhookSender initializes a new ChatWebhookSender instance.
+func NewChatWebhookSender(webhookURL string, timeout time.Duration) *ChatWebhookSender {
+	return &ChatWebhookSender{
+		client: &http.Client{
+			Timeout: timeout,
+		},
+		webhookURL: webhookURL,
+	}
+}
+
+// chatWebhookRequest represents the payload understood by both Slack and Teams incoming webhooks.
+type chatWebhookRequest struct {
+	// Channel overrides the default channel of the webhook where supported, e.g. "#alerts".
+	Channel string `json:"channel,omitempty"`
+	Text    string `json:"text"`
+}
+
+// Send posts the content to the webhook. The recipient is used as the channel override.
+// Incoming webhooks don't return a message ID, so a local one is generated.
+func (s *ChatWebhookSender) Send(ctx context.Context, recipient, content string) (*NotificationResponse, error) {
+	payload := chatWebhookRequest{
+		Channel: recipient,
+		Text:    content,
+	}
+
+	resp, err := postJSON(ctx, s.client, s.webhookURL, payload, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Slack responds with "ok" and Teams with "1".
+	message, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return nil, err
+	}
+
+	return &NotificationResponse{
+		Message:   string(message),
+		MessageID: uuid.New().String(),
+	}, nil
+}
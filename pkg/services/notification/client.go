@@ -1,13 +1,10 @@
 package notification
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"io"
 	"net/http"
 	"time"
-
-	"github.com/mehmetalisavas/message-sender/pkg/retry"
 )
 
 type NotificationSender interface {
@@ -18,16 +15,53 @@ type NotificationSender interface {
 type NotificationService struct {
 	client  *http.Client
 	baseURL string
+	auth    Authenticator
+	mapping *Mapping
+}
+
+// Option configures a NotificationService.
+type Option func(*NotificationService)
+
+// WithAuthenticator authenticates or signs every request with the given authenticator.
+func WithAuthenticator(auth Authenticator) Option {
+	return func(ns *NotificationService) {
+		ns.auth = auth
+	}
+}
+
+// WithMapping encodes the requests and decodes the responses with the given mapping
+// instead of the default {"to","content"} and {"message","messageId"} payloads.
+func WithMapping(mapping *Mapping) Option {
+	return func(ns *NotificationService) {
+		ns.mapping = mapping
+	}
+}
+
+// defaultMapping is the compiled DefaultMappingConfig.
+var defaultMapping = mustMapping(DefaultMappingConfig)
+
+func mustMapping(cfg MappingConfig) *Mapping {
+	m, err := NewMapping(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return m
 }
 
 // NewNotificationService initializes a new NotificationService instance
-func NewNotificationService(baseURL string, timeout time.Duration) *NotificationService {
-	return &NotificationService{
+func NewNotificationService(baseURL string, timeout time.Duration, opts ...Option) *NotificationService {
+	ns := &NotificationService{
 		client: &http.Client{
 			Timeout: timeout,
 		},
 		baseURL: baseURL,
+		mapping: defaultMapping,
+	}
+	for _, opt := range opts {
+		opt(ns)
 	}
+
+	return ns
 }
 
 // NotificationRequest represents the request payload
@@ -40,40 +74,28 @@ type NotificationRequest struct {
 type NotificationResponse struct {
 	Message   string `json:"message"`
 	MessageID string `json:"messageId"`
+	// Provider is the name of the provider that handled the notification, set by the Router.
+	Provider string `json:"-"`
 }
 
-// Send sends a notification to the notification service
+// Send sends a notification to the notification service.
+// A successful response must include a message ID, otherwise ErrMissingMessageID is returned.
 func (ns *NotificationService) Send(ctx context.Context, recipient, content string) (*NotificationResponse, error) {
-	payload := NotificationRequest{
-		To:      recipient,
-		Content: content,
-	}
-
-	jsonData, err := json.Marshal(payload)
+	body, err := ns.mapping.Request(recipient, content)
 	if err != nil {
 		return nil, err
 	}
 
-	requestFn := func() (*http.Response, error) {
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, ns.baseURL, bytes.NewBuffer(jsonData))
-		if err != nil {
-			return nil, err
-		}
-
-		req.Header.Set("Content-Type", "application/json")
-		return ns.client.Do(req)
-	}
-
-	resp, err := retry.Retry(ctx, requestFn, retry.DefaultConfig)
+	resp, err := post(ctx, ns.client, ns.baseURL, "application/json", body, ns.auth)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	var response NotificationResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
 		return nil, err
 	}
 
-	return &response, nil
+	return ns.mapping.Response(respBody)
 }
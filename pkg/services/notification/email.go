@@ -0,0 +1,148 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Make sure EmailSender implements NotificationSender interface.
+var _ NotificationSender = (*EmailSender)(nil)
+
+// EmailSender sends plain text emails through an SMTP server.
+// STARTTLS is used whenever the server supports it.
+type EmailSender struct {
+	host    string
+	addr    string
+	auth    smtp.Auth
+	from    string
+	subject string
+	timeout time.Duration
+}
+
+// NewEmailSender initializes a new EmailSender instance.
+// Authentication is skipped when username is empty.
+func NewEmailSender(host string, port int, username, password, from, subject string, timeout time.Duration) *EmailSender {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &EmailSender{
+		host:    host,
+		addr:    net.JoinHostPort(host, strconv.Itoa(port)),
+		auth:    auth,
+		from:    from,
+		subject: subject,
+		timeout: timeout,
+	}
+}
+
+// Send sends the content as an email to the recipient address.
+func (s *EmailSender) Send(ctx context.Context, recipient, content string) (*NotificationResponse, error) {
+	to, err := mail.ParseAddress(recipient)
+	if err != nil {
+		return nil, fmt.Errorf("invalid email recipient: %w", err)
+	}
+
+	messageID := emailMessageID(ctx)
+	msg := s.buildMessage(to.Address, content, messageID)
+
+	dialer := net.Dialer{Timeout: s.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(s.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return nil, err
+		}
+	}
+	if s.auth != nil {
+		if err := client.Auth(s.auth); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := client.Mail(s.from); err != nil {
+		return nil, err
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return nil, err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := client.Quit(); err != nil {
+		return nil, err
+	}
+
+	return &NotificationResponse{
+		Message:   "Accepted",
+		MessageID: messageID,
+	}, nil
+}
+
+// emailMessageID returns the Message-ID of an email sent with ctx. It is the idempotency key of ctx when
+// there is one, so that an email sent again after a crash is a duplicate that mail stores can drop
+// instead of a new email.
+func emailMessageID(ctx context.Context) string {
+	if key := IdempotencyKey(ctx); key != "" {
+		return key
+	}
+	return uuid.New().String()
+}
+
+// buildMessage builds a plain text RFC 5322 message.
+func (s *EmailSender) buildMessage(to, content, messageID string) []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "From: %s\r\n", s.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", to)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", s.subject))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", messageID, s.host)
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(content)
+	buf.WriteString("\r\n")
+
+	return buf.Bytes()
+}
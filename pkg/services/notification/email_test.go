@@ -0,0 +1,25 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestEmailMessageID(t *testing.T) {
+	ctx := WithIdempotencyKey(context.Background(), "message-42")
+
+	// Every attempt of a message gets the same Message-ID.
+	if first, second := emailMessageID(ctx), emailMessageID(ctx); first != "message-42" || second != first {
+		t.Errorf("emailMessageID() = %q and %q, want %q", first, second, "message-42")
+	}
+	if first, second := emailMessageID(context.Background()), emailMessageID(context.Background()); first == "" || first == second {
+		t.Errorf("expected unique Message-IDs without an idempotency key, got %q and %q", first, second)
+	}
+
+	sender := NewEmailSender("smtp.example.com", 587, "", "", "noreply@example.com", "Notification", 0)
+	msg := sender.buildMessage("user@example.com", "hello", emailMessageID(ctx))
+	if !bytes.Contains(msg, []byte("Message-ID: <message-42@smtp.example.com>\r\n")) {
+		t.Errorf("buildMessage() = %q, want the Message-ID of the idempotency key", msg)
+	}
+}
@@ -0,0 +1,79 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/mehmetalisavas/message-sender/pkg/retry"
+)
+
+// maxDrainSize limits how much of a rejected response body is drained to reuse the connection.
+const maxDrainSize = 64 << 10
+
+// postJSON posts the payload as JSON to the given url with the retry mechanism.
+// Every attempt is authenticated with auth unless it is nil.
+// The caller is responsible for closing the response body.
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}, auth Authenticator) (*http.Response, error) {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return post(ctx, client, url, "application/json", jsonData, auth)
+}
+
+// post posts the body to the given url with the retry mechanism.
+// Every attempt is authenticated with auth unless it is nil and carries the idempotency key of ctx.
+// An attempt answered with 401 is sent once more if auth could renew its cached credentials.
+// The caller is responsible for closing the response body.
+func post(ctx context.Context, client *http.Client, url, contentType string, body []byte, auth Authenticator) (*http.Response, error) {
+	send := func() (*http.Request, *http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		req.Header.Set("Content-Type", contentType)
+		if key := IdempotencyKey(ctx); key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		if auth != nil {
+			if err := auth.Authenticate(req); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		resp, err := client.Do(req)
+		return req, resp, err
+	}
+
+	requestFn := func() (*http.Response, error) {
+		req, resp, err := send()
+		if err != nil || resp.StatusCode != http.StatusUnauthorized {
+			return resp, err
+		}
+
+		invalidator, ok := auth.(Invalidator)
+		if !ok || !invalidator.Invalidate(req) {
+			return resp, nil
+		}
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainSize))
+		resp.Body.Close()
+
+		_, resp, err = send()
+		return resp, err
+	}
+
+	config := retry.DefaultConfig
+	config.OnAttempt = func(attempt retry.Attempt) {
+		if attempt.Retry {
+			log.Printf("request to %s failed (attempt %d), retrying in %v: %v\n", url, attempt.Number, attempt.Delay, attempt.Err)
+		}
+	}
+
+	return retry.Retry(ctx, requestFn, config)
+}
@@ -0,0 +1,21 @@
+package notification
+
+import "context"
+
+// IdempotencyKeyHeader is the request header that carries the idempotency key to providers,
+// so that they can drop a request they already handled.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+type idempotencyKeyContextKey struct{}
+
+// WithIdempotencyKey returns a context whose notification requests carry the given idempotency key.
+// A message must be sent with the same key on every attempt.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// IdempotencyKey returns the idempotency key of the context, empty if there is none.
+func IdempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyContextKey{}).(string)
+	return key
+}
@@ -0,0 +1,162 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+var (
+	ErrMissingMessageID = errors.New("notification response has no message ID")
+	ErrProviderRejected = errors.New("notification rejected by provider")
+)
+
+// DefaultMappingConfig matches the {"to","content"} request and {"message","messageId"} response of the webhook.
+var DefaultMappingConfig = MappingConfig{
+	RequestTemplate: `{"to": {{json .To}}, "content": {{json .Content}}}`,
+	MessageIDPath:   "messageId",
+	MessagePath:     "message",
+}
+
+// MappingConfig describes how notifications are encoded for a provider and how its responses are decoded.
+//
+// RequestTemplate is a text/template rendering the JSON request body, with the .To and .Content
+// fields and a json function to encode them, e.g. {"phone": {{json .To}}, "text": {{json .Content}}}.
+// The paths are dot separated JSON paths into the response, array elements are addressed
+// by their index, e.g. "messages.0.id".
+type MappingConfig struct {
+	RequestTemplate string `json:"request_template"`
+	// MessageIDPath is required, a successful response without a message ID is rejected.
+	MessageIDPath string `json:"message_id_path"`
+	// SuccessPath is optional. When it is set, the response is successful if the value equals
+	// SuccessValue, or is true when SuccessValue is empty.
+	SuccessPath  string `json:"success_path"`
+	SuccessValue string `json:"success_value"`
+	// MessagePath optionally extracts a human readable status message.
+	MessagePath string `json:"message_path"`
+}
+
+// Mapping is a validated and compiled MappingConfig.
+type Mapping struct {
+	cfg  MappingConfig
+	tmpl *template.Template
+}
+
+// mappingData is the data the request template is executed with.
+type mappingData struct {
+	To      string
+	Content string
+}
+
+// NewMapping validates and compiles the given mapping configuration.
+func NewMapping(cfg MappingConfig) (*Mapping, error) {
+	if cfg.MessageIDPath == "" {
+		return nil, errors.New("mapping requires a message ID path")
+	}
+
+	tmpl, err := template.New("request").Option("missingkey=error").Funcs(template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(cfg.RequestTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request template: %w", err)
+	}
+
+	m := &Mapping{cfg: cfg, tmpl: tmpl}
+	if _, err := m.Request("+905555555555", `sample "content"`); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Request renders the JSON request body of the notification.
+func (m *Mapping) Request(recipient, content string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := m.tmpl.Execute(&buf, mappingData{To: recipient, Content: content}); err != nil {
+		return nil, fmt.Errorf("failed to render request template: %w", err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, errors.New("request template renders invalid JSON")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Response extracts the notification response from the JSON response body of the provider.
+func (m *Mapping) Response(body []byte) (*NotificationResponse, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid notification response: %w", err)
+	}
+
+	message, _ := lookupPath(doc, m.cfg.MessagePath)
+
+	if m.cfg.SuccessPath != "" {
+		value, ok := lookupPath(doc, m.cfg.SuccessPath)
+		expected := m.cfg.SuccessValue
+		if expected == "" {
+			expected = "true"
+		}
+		if !ok || value != expected {
+			return nil, fmt.Errorf("%w: %s is %q: %s", ErrProviderRejected, m.cfg.SuccessPath, value, message)
+		}
+	}
+
+	messageID, ok := lookupPath(doc, m.cfg.MessageIDPath)
+	if !ok || messageID == "" {
+		return nil, fmt.Errorf("%w at %s", ErrMissingMessageID, m.cfg.MessageIDPath)
+	}
+
+	return &NotificationResponse{
+		Message:   message,
+		MessageID: messageID,
+	}, nil
+}
+
+// lookupPath returns the scalar value at the dot separated path as a string.
+func lookupPath(doc interface{}, path string) (string, bool) {
+	if path == "" {
+		return "", false
+	}
+
+	current := doc
+	for _, key := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[key]
+			if !ok {
+				return "", false
+			}
+			current = value
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return "", false
+			}
+			current = node[i]
+		default:
+			return "", false
+		}
+	}
+
+	switch value := current.(type) {
+	case string:
+		return value, true
+	case json.Number:
+		return value.String(), true
+	case bool:
+		return strconv.FormatBool(value), true
+	default:
+		return "", false
+	}
+}
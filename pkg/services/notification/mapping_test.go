@@ -0,0 +1,99 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMapping_Request(t *testing.T) {
+	m, err := NewMapping(MappingConfig{
+		RequestTemplate: `{"phone": {{json .To}}, "sms": {"text": {{json .Content}}}}`,
+		MessageIDPath:   "id",
+	})
+	if err != nil {
+		t.Fatalf("NewMapping() error = %v", err)
+	}
+
+	body, err := m.Request("+905555555555", `say "hi"`)
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+
+	var got struct {
+		Phone string `json:"phone"`
+		SMS   struct {
+			Text string `json:"text"`
+		} `json:"sms"`
+	}
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("Request() rendered invalid JSON %s: %v", body, err)
+	}
+	if got.Phone != "+905555555555" || got.SMS.Text != `say "hi"` {
+		t.Errorf("Request() = %s", body)
+	}
+}
+
+func TestNewMapping_Invalid(t *testing.T) {
+	tests := map[string]MappingConfig{
+		"missing message ID path": {RequestTemplate: `{"to": {{json .To}}}`},
+		"invalid template":        {RequestTemplate: `{"to": {{json .To}`, MessageIDPath: "id"},
+		"unknown field":           {RequestTemplate: `{"to": {{json .Phone}}}`, MessageIDPath: "id"},
+		"invalid JSON":            {RequestTemplate: `{"to": {{.To}}}`, MessageIDPath: "id"},
+	}
+
+	for name, cfg := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewMapping(cfg); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestMapping_Response(t *testing.T) {
+	m, err := NewMapping(MappingConfig{
+		RequestTemplate: DefaultMappingConfig.RequestTemplate,
+		MessageIDPath:   "data.messages.0.id",
+		SuccessPath:     "status",
+		SuccessValue:    "queued",
+		MessagePath:     "description",
+	})
+	if err != nil {
+		t.Fatalf("NewMapping() error = %v", err)
+	}
+
+	resp, err := m.Response([]byte(`{"status": "queued", "description": "ok", "data": {"messages": [{"id": 12345}]}}`))
+	if err != nil {
+		t.Fatalf("Response() error = %v", err)
+	}
+	if resp.MessageID != "12345" || resp.Message != "ok" {
+		t.Errorf("Response() = %+v", resp)
+	}
+
+	_, err = m.Response([]byte(`{"status": "rejected", "description": "invalid number"}`))
+	if !errors.Is(err, ErrProviderRejected) {
+		t.Errorf("Response() error = %v, want %v", err, ErrProviderRejected)
+	}
+
+	_, err = m.Response([]byte(`{"status": "queued", "data": {"messages": []}}`))
+	if !errors.Is(err, ErrMissingMessageID) {
+		t.Errorf("Response() error = %v, want %v", err, ErrMissingMessageID)
+	}
+}
+
+func TestNotificationService_StrictMessageID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"message": "Accepted"}`))
+	}))
+	defer server.Close()
+
+	ns := NewNotificationService(server.URL, time.Second)
+	if _, err := ns.Send(context.Background(), "+905555555555", "hello"); !errors.Is(err, ErrMissingMessageID) {
+		t.Errorf("Send() error = %v, want %v", err, ErrMissingMessageID)
+	}
+}
@@ -0,0 +1,67 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Make sure PushSender implements NotificationSender interface.
+var _ NotificationSender = (*PushSender)(nil)
+
+// PushSender sends push notifications through an HTTP push API that accepts a device token.
+type PushSender struct {
+	client  *http.Client
+	baseURL string
+	title   string
+}
+
+// NewPushSender initializes a new PushSender instance. title is shown above the content on the device.
+func NewPushSender(baseURL, title string, timeout time.Duration) *PushSender {
+	return &PushSender{
+		client: &http.Client{
+			Timeout: timeout,
+		},
+		baseURL: baseURL,
+		title:   title,
+	}
+}
+
+// PushRequest represents the push notification payload.
+type PushRequest struct {
+	Token string `json:"token"`
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body"`
+}
+
+// pushResponse represents the response of the push API.
+type pushResponse struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+// Send sends the content as a push notification to the device token given as recipient.
+func (s *PushSender) Send(ctx context.Context, recipient, content string) (*NotificationResponse, error) {
+	payload := PushRequest{
+		Token: recipient,
+		Title: s.title,
+		Body:  content,
+	}
+
+	resp, err := postJSON(ctx, s.client, s.baseURL, payload, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var response pushResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+
+	return &NotificationResponse{
+		Message:   response.Status,
+		MessageID: response.ID,
+	}, nil
+}
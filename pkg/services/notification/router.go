@@ -0,0 +1,251 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Make sure Router implements NotificationSender interface.
+var _ NotificationSender = (*Router)(nil)
+
+var ErrNoRoute = errors.New("no notification route for recipient")
+
+// Provider is a named notification sender used by the Router.
+type Provider struct {
+	Name   string
+	Sender NotificationSender
+	// Priority orders the providers of a route, lower priorities are tried first.
+	Priority int
+	// Weight is the relative share of the provider among the providers with the same priority.
+	// Non-positive weights count as 1.
+	Weight int
+	// Timeout bounds a single send through the provider, including its retries. Zero means no timeout.
+	Timeout time.Duration
+}
+
+// Route selects the providers of the recipients starting with Prefix, e.g. "+90".
+// A route with an empty prefix matches every recipient.
+type Route struct {
+	Prefix    string
+	Providers []Provider
+}
+
+// Router sends notifications through the providers of the longest matching route.
+// It fails over to the next provider on error or timeout and records which provider
+// handled the notification in NotificationResponse.Provider. When every provider is
+// short-circuited by its breaker, the CircuitOpenError with the soonest retry is returned.
+type Router struct {
+	routes []Route
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// NewRouter creates a new Router instance with the given routes.
+func NewRouter(routes []Route) *Router {
+	sorted := make([]Route, len(routes))
+	copy(sorted, routes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i].Prefix) > len(sorted[j].Prefix)
+	})
+
+	return &Router{
+		routes: sorted,
+		rnd:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Send sends the notification through the providers of the matching route until one succeeds.
+func (r *Router) Send(ctx context.Context, recipient, content string) (*NotificationResponse, error) {
+	route, ok := r.route(recipient)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoRoute, recipient)
+	}
+
+	var (
+		errs    []error
+		openErr *CircuitOpenError
+		allOpen = true
+	)
+	for _, provider := range r.order(route.Providers) {
+		resp, err := sendWithTimeout(ctx, provider, recipient, content)
+		if err == nil {
+			resp.Provider = provider.Name
+			return resp, nil
+		}
+
+		// Keep the soonest retry of the open circuits, but don't let them mask other failures.
+		var e *CircuitOpenError
+		if errors.As(err, &e) {
+			if openErr == nil || e.RetryAfter < openErr.RetryAfter {
+				openErr = e
+			}
+			errs = append(errs, fmt.Errorf("provider %s: %v", provider.Name, err))
+		} else {
+			allOpen = false
+			errs = append(errs, fmt.Errorf("provider %s: %w", provider.Name, err))
+		}
+
+		// Don't fail over when the caller gave up.
+		if ctx.Err() != nil {
+			return nil, errors.Join(errs...)
+		}
+	}
+
+	if allOpen && openErr != nil {
+		return nil, openErr
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// route returns the route with the longest prefix matching the recipient.
+func (r *Router) route(recipient string) (Route, bool) {
+	for _, route := range r.routes {
+		if strings.HasPrefix(recipient, route.Prefix) {
+			return route, true
+		}
+	}
+
+	return Route{}, false
+}
+
+// order returns the providers sorted by priority, with a weighted random order among the same priority.
+func (r *Router) order(providers []Provider) []Provider {
+	byPriority := make(map[int][]Provider)
+	priorities := make([]int, 0)
+	for _, p := range providers {
+		if _, ok := byPriority[p.Priority]; !ok {
+			priorities = append(priorities, p.Priority)
+		}
+		byPriority[p.Priority] = append(byPriority[p.Priority], p)
+	}
+	sort.Ints(priorities)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ordered := make([]Provider, 0, len(providers))
+	for _, priority := range priorities {
+		candidates := byPriority[priority]
+		for len(candidates) > 0 {
+			i := r.pickWeighted(candidates)
+			ordered = append(ordered, candidates[i])
+			candidates = append(candidates[:i:i], candidates[i+1:]...)
+		}
+	}
+
+	return ordered
+}
+
+// pickWeighted returns the index of a randomly picked provider according to the weights. r.mu must be held.
+func (r *Router) pickWeighted(providers []Provider) int {
+	total := 0
+	for _, p := range providers {
+		total += providerWeight(p)
+	}
+
+	n := r.rnd.Intn(total)
+	for i, p := range providers {
+		n -= providerWeight(p)
+		if n < 0 {
+			return i
+		}
+	}
+
+	return len(providers) - 1
+}
+
+func providerWeight(p Provider) int {
+	if p.Weight <= 0 {
+		return 1
+	}
+	return p.Weight
+}
+
+func sendWithTimeout(ctx context.Context, provider Provider, recipient, content string) (*NotificationResponse, error) {
+	if provider.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, provider.Timeout)
+		defer cancel()
+	}
+
+	return provider.Sender.Send(ctx, recipient, content)
+}
+
+// ProviderConfig represents the configuration of a webhook notification provider.
+type ProviderConfig struct {
+	Name           string `json:"name"`
+	URL            string `json:"url"`
+	TimeoutSeconds int    `json:"timeout_seconds"`
+	// Mapping overrides the request and response format of the provider, DefaultMappingConfig when nil.
+	Mapping *MappingConfig `json:"mapping"`
+}
+
+// RouteConfig represents the configuration of a route.
+type RouteConfig struct {
+	Prefix    string                `json:"prefix"`
+	Providers []RouteProviderConfig `json:"providers"`
+}
+
+// RouteProviderConfig references a configured provider from a route.
+type RouteProviderConfig struct {
+	Name     string `json:"name"`
+	Priority int    `json:"priority"`
+	Weight   int    `json:"weight"`
+}
+
+// ParseRoutingConfig parses the JSON encoded provider and route configurations.
+func ParseRoutingConfig(providersJSON, routesJSON string) ([]ProviderConfig, []RouteConfig, error) {
+	var providers []ProviderConfig
+	if err := json.Unmarshal([]byte(providersJSON), &providers); err != nil {
+		return nil, nil, fmt.Errorf("invalid providers config: %w", err)
+	}
+
+	var routes []RouteConfig
+	if err := json.Unmarshal([]byte(routesJSON), &routes); err != nil {
+		return nil, nil, fmt.Errorf("invalid routes config: %w", err)
+	}
+
+	return providers, routes, nil
+}
+
+// BuildRouter creates a Router from the route configurations using the senders of the named providers.
+func BuildRouter(providers []ProviderConfig, routes []RouteConfig, senders map[string]NotificationSender) (*Router, error) {
+	timeouts := make(map[string]time.Duration, len(providers))
+	for _, p := range providers {
+		timeouts[p.Name] = time.Duration(p.TimeoutSeconds) * time.Second
+	}
+
+	built := make([]Route, 0, len(routes))
+	for _, rc := range routes {
+		if len(rc.Providers) == 0 {
+			return nil, fmt.Errorf("route %q has no providers", rc.Prefix)
+		}
+
+		route := Route{Prefix: rc.Prefix}
+		for _, pc := range rc.Providers {
+			sender, ok := senders[pc.Name]
+			if !ok {
+				return nil, fmt.Errorf("route %q references unknown provider %q", rc.Prefix, pc.Name)
+			}
+			route.Providers = append(route.Providers, Provider{
+				Name:     pc.Name,
+				Sender:   sender,
+				Priority: pc.Priority,
+				Weight:   pc.Weight,
+				Timeout:  timeouts[pc.Name],
+			})
+		}
+		built = append(built, route)
+	}
+
+	return NewRouter(built), nil
+}
@@ -0,0 +1,146 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// errProviderDown is the transport error of a provider that can't be reached.
+var errProviderDown = &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+type funcSender func(ctx context.Context, recipient, content string) (*NotificationResponse, error)
+
+func (f funcSender) Send(ctx context.Context, recipient, content string) (*NotificationResponse, error) {
+	return f(ctx, recipient, content)
+}
+
+func okSender(id string) NotificationSender {
+	return funcSender(func(ctx context.Context, recipient, content string) (*NotificationResponse, error) {
+		return &NotificationResponse{MessageID: id}, nil
+	})
+}
+
+func failingSender() NotificationSender {
+	return funcSender(func(ctx context.Context, recipient, content string) (*NotificationResponse, error) {
+		return nil, errProviderDown
+	})
+}
+
+func TestRouter_FailsOverOnError(t *testing.T) {
+	router := NewRouter([]Route{{
+		Providers: []Provider{
+			{Name: "primary", Sender: failingSender(), Priority: 1},
+			{Name: "backup", Sender: okSender("backup-1"), Priority: 2},
+		},
+	}})
+
+	resp, err := router.Send(context.Background(), "+905555555555", "hello")
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if resp.Provider != "backup" || resp.MessageID != "backup-1" {
+		t.Errorf("Send() = %+v, want the backup provider", resp)
+	}
+}
+
+func TestRouter_FailsOverOnTimeout(t *testing.T) {
+	slow := funcSender(func(ctx context.Context, recipient, content string) (*NotificationResponse, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	router := NewRouter([]Route{{
+		Providers: []Provider{
+			{Name: "slow", Sender: slow, Priority: 1, Timeout: 10 * time.Millisecond},
+			{Name: "backup", Sender: okSender("backup-1"), Priority: 2},
+		},
+	}})
+
+	resp, err := router.Send(context.Background(), "+905555555555", "hello")
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if resp.Provider != "backup" {
+		t.Errorf("Send() provider = %q, want %q", resp.Provider, "backup")
+	}
+}
+
+func TestRouter_AllProvidersFail(t *testing.T) {
+	router := NewRouter([]Route{{
+		Providers: []Provider{
+			{Name: "primary", Sender: failingSender()},
+			{Name: "backup", Sender: failingSender()},
+		},
+	}})
+
+	if _, err := router.Send(context.Background(), "+905555555555", "hello"); err == nil {
+		t.Fatalf("expected error when all providers fail")
+	}
+}
+
+func TestRouter_LongestPrefixMatch(t *testing.T) {
+	router := NewRouter([]Route{
+		{Prefix: "", Providers: []Provider{{Name: "global", Sender: okSender("g")}}},
+		{Prefix: "+90", Providers: []Provider{{Name: "turkey", Sender: okSender("t")}}},
+		{Prefix: "+9053", Providers: []Provider{{Name: "turkey-mobile", Sender: okSender("m")}}},
+	})
+
+	tests := map[string]string{
+		"+905355555555":  "turkey-mobile",
+		"+902125555555":  "turkey",
+		"+4915155555555": "global",
+	}
+	for recipient, want := range tests {
+		resp, err := router.Send(context.Background(), recipient, "hello")
+		if err != nil {
+			t.Fatalf("Send(%s) error = %v", recipient, err)
+		}
+		if resp.Provider != want {
+			t.Errorf("Send(%s) provider = %q, want %q", recipient, resp.Provider, want)
+		}
+	}
+
+	router = NewRouter([]Route{{Prefix: "+90", Providers: []Provider{{Name: "turkey", Sender: okSender("t")}}}})
+	if _, err := router.Send(context.Background(), "+4915155555555", "hello"); !errors.Is(err, ErrNoRoute) {
+		t.Errorf("Send() error = %v, want %v", err, ErrNoRoute)
+	}
+}
+
+func TestRouter_WeightedRouting(t *testing.T) {
+	router := NewRouter([]Route{{
+		Providers: []Provider{
+			{Name: "heavy", Sender: okSender("h"), Weight: 90},
+			{Name: "light", Sender: okSender("l"), Weight: 10},
+		},
+	}})
+
+	counts := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		resp, err := router.Send(context.Background(), "+905555555555", "hello")
+		if err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+		counts[resp.Provider]++
+	}
+
+	if counts["heavy"] < 800 || counts["light"] < 20 {
+		t.Errorf("unexpected weighted distribution: %v", counts)
+	}
+}
+
+func TestBuildRouter_UnknownProvider(t *testing.T) {
+	providers, routes, err := ParseRoutingConfig(
+		`[{"name": "primary", "url": "http://localhost", "timeout_seconds": 5}]`,
+		`[{"prefix": "+90", "providers": [{"name": "secondary", "priority": 1}]}]`,
+	)
+	if err != nil {
+		t.Fatalf("ParseRoutingConfig() error = %v", err)
+	}
+
+	_, err = BuildRouter(providers, routes, map[string]NotificationSender{"primary": okSender("p")})
+	if err == nil {
+		t.Errorf("expected error for an unknown provider")
+	}
+}
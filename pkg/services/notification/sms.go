@@ -0,0 +1,69 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Make sure SMSGatewaySender implements NotificationSender interface.
+var _ NotificationSender = (*SMSGatewaySender)(nil)
+
+// SMSGatewaySender sends SMS through a generic HTTP gateway that accepts
+// form encoded "from", "to" and "text" parameters.
+type SMSGatewaySender struct {
+	client  *http.Client
+	baseURL string
+	from    string
+}
+
+// NewSMSGatewaySender initializes a new SMSGatewaySender instance.
+// from is the sender ID shown to the recipient, the gateway's default is used when it is empty.
+func NewSMSGatewaySender(baseURL, from string, timeout time.Duration) *SMSGatewaySender {
+	return &SMSGatewaySender{
+		client: &http.Client{
+			Timeout: timeout,
+		},
+		baseURL: baseURL,
+		from:    from,
+	}
+}
+
+// smsGatewayResponse covers the message ID fields commonly returned by SMS gateways.
+type smsGatewayResponse struct {
+	ID        string `json:"id"`
+	MessageID string `json:"message_id"`
+	Status    string `json:"status"`
+}
+
+// Send sends the content as SMS to the recipient.
+func (s *SMSGatewaySender) Send(ctx context.Context, recipient, content string) (*NotificationResponse, error) {
+	form := url.Values{}
+	form.Set("to", recipient)
+	form.Set("text", content)
+	if s.from != "" {
+		form.Set("from", s.from)
+	}
+	resp, err := post(ctx, s.client, s.baseURL, "application/x-www-form-urlencoded", []byte(form.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var response smsGatewayResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+
+	messageID := response.MessageID
+	if messageID == "" {
+		messageID = response.ID
+	}
+
+	return &NotificationResponse{
+		Message:   response.Status,
+		MessageID: messageID,
+	}, nil
+}
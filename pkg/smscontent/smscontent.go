@@ -0,0 +1,108 @@
+// Package smscontent analyses SMS content the way carriers bill it: the
+// content is encoded in GSM-7 when possible and in UCS-2 otherwise, and split
+// into segments when it doesn't fit a single message.
+package smscontent
+
+// Encoding is the character encoding an SMS is sent with.
+type Encoding string
+
+const (
+	EncodingGSM7 Encoding = "GSM-7"
+	EncodingUCS2 Encoding = "UCS-2"
+)
+
+// Capacities of a single message and of every segment of a multipart message,
+// in septets for GSM-7 and in UTF-16 code units for UCS-2. Multipart segments
+// carry a header that takes up part of the payload.
+const (
+	gsm7SingleCapacity    = 160
+	gsm7MultipartCapacity = 153
+	ucs2SingleCapacity    = 70
+	ucs2MultipartCapacity = 67
+)
+
+// gsm7Basic is the GSM 03.38 basic character set, every character takes one septet.
+const gsm7Basic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+	"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+
+// gsm7Extension is the GSM 03.38 extension table, every character takes two septets.
+const gsm7Extension = "\f^{}\\[~]|€"
+
+var (
+	gsm7BasicSet     = runeSet(gsm7Basic)
+	gsm7ExtensionSet = runeSet(gsm7Extension)
+)
+
+// Analysis describes how a content is sent as SMS.
+type Analysis struct {
+	Encoding Encoding `json:"encoding"`
+	// Length is the length of the encoded content, in septets for GSM-7 and in UTF-16 code units for UCS-2.
+	Length   int `json:"length"`
+	Segments int `json:"segments"`
+	// BillableUnits is the number of messages the carrier charges for.
+	BillableUnits int `json:"billable_units"`
+}
+
+// Analyze returns the encoding and segment count of content.
+func Analyze(content string) Analysis {
+	encoding := EncodingGSM7
+	for _, r := range content {
+		if !gsm7BasicSet[r] && !gsm7ExtensionSet[r] {
+			encoding = EncodingUCS2
+			break
+		}
+	}
+
+	// Characters aren't split across segments, so the widths are kept per character.
+	widths := make([]int, 0, len(content))
+	length := 0
+	for _, r := range content {
+		width := 1
+		switch {
+		case encoding == EncodingGSM7 && gsm7ExtensionSet[r]:
+			width = 2
+		case encoding == EncodingUCS2 && r > 0xFFFF:
+			// Characters outside the basic multilingual plane take a surrogate pair.
+			width = 2
+		}
+		widths = append(widths, width)
+		length += width
+	}
+
+	singleCapacity, multipartCapacity := gsm7SingleCapacity, gsm7MultipartCapacity
+	if encoding == EncodingUCS2 {
+		singleCapacity, multipartCapacity = ucs2SingleCapacity, ucs2MultipartCapacity
+	}
+
+	segments := 0
+	switch {
+	case length == 0:
+	case length <= singleCapacity:
+		segments = 1
+	default:
+		used := 0
+		segments = 1
+		for _, width := range widths {
+			if used+width > multipartCapacity {
+				segments++
+				used = 0
+			}
+			used += width
+		}
+	}
+
+	return Analysis{
+		Encoding:      encoding,
+		Length:        length,
+		Segments:      segments,
+		BillableUnits: segments,
+	}
+}
+
+func runeSet(s string) map[rune]bool {
+	set := make(map[rune]bool, len(s))
+	for _, r := range s {
+		set[r] = true
+	}
+	return set
+}
@@ -0,0 +1,33 @@
+package smscontent
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    Analysis
+	}{
+		{name: "empty", content: "", want: Analysis{Encoding: EncodingGSM7}},
+		{name: "gsm7", content: "Hello world!", want: Analysis{Encoding: EncodingGSM7, Length: 12, Segments: 1, BillableUnits: 1}},
+		{name: "gsm7 full", content: strings.Repeat("a", 160), want: Analysis{Encoding: EncodingGSM7, Length: 160, Segments: 1, BillableUnits: 1}},
+		{name: "gsm7 multipart", content: strings.Repeat("a", 161), want: Analysis{Encoding: EncodingGSM7, Length: 161, Segments: 2, BillableUnits: 2}},
+		{name: "gsm7 extension", content: "Price: 5€ [sale]", want: Analysis{Encoding: EncodingGSM7, Length: 19, Segments: 1, BillableUnits: 1}},
+		{name: "gsm7 extension not split", content: strings.Repeat("a", 152) + "€" + strings.Repeat("a", 10), want: Analysis{Encoding: EncodingGSM7, Length: 164, Segments: 2, BillableUnits: 2}},
+		{name: "ucs2", content: "Merhaba dünya, nasılsın?", want: Analysis{Encoding: EncodingUCS2, Length: 24, Segments: 1, BillableUnits: 1}},
+		{name: "ucs2 multipart", content: strings.Repeat("ş", 71), want: Analysis{Encoding: EncodingUCS2, Length: 71, Segments: 2, BillableUnits: 2}},
+		{name: "ucs2 surrogate pair", content: "Hi 👋", want: Analysis{Encoding: EncodingUCS2, Length: 5, Segments: 1, BillableUnits: 1}},
+		{name: "ucs2 three segments", content: strings.Repeat("ı", 135), want: Analysis{Encoding: EncodingUCS2, Length: 135, Segments: 3, BillableUnits: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Analyze(tt.content); got != tt.want {
+				t.Errorf("Analyze() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}